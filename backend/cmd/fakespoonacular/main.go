@@ -0,0 +1,42 @@
+// Command fakespoonacular serves the bundled Spoonacular fixtures over HTTP so
+// the backend can run offline. Start it and set
+// SPOONACULAR_BASE_URL=http://localhost:8090 before starting the API server.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"recipe-finder-backend/fakespoonacular"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "address to listen on")
+	fixturesDir := flag.String("fixtures", "", "directory with ingredients.json and recipes/*.json (defaults to the bundled fixtures)")
+	dailyLimit := flag.Float64("daily-limit", fakespoonacular.DefaultDailyLimit, "quota points available before requests fail with 402")
+	failCount := flag.Int("fail-count", 0, "number of initial requests to fail, to exercise retries")
+	failStatus := flag.Int("fail-status", 503, "HTTP status of the failed requests")
+	latency := flag.Duration("latency", 0, "delay before every response, to exercise timeouts and cancellation")
+	flag.Parse()
+
+	var server *fakespoonacular.Server
+	var err error
+	if *fixturesDir != "" {
+		server, err = fakespoonacular.NewFromFS(os.DirFS(*fixturesDir))
+	} else {
+		server, err = fakespoonacular.New()
+	}
+	if err != nil {
+		log.Fatalf("Failed to load fixtures: %v", err)
+	}
+	server.SetDailyLimit(*dailyLimit)
+	server.FailNext(*failCount, *failStatus)
+	server.SetLatency(*latency)
+
+	fmt.Printf("🧪 Fake Spoonacular API listening on %s\n", *addr)
+	fmt.Printf("👉 Set SPOONACULAR_BASE_URL=http://localhost%s to use it\n", *addr)
+	log.Fatal(http.ListenAndServe(*addr, server))
+}
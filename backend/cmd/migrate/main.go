@@ -0,0 +1,59 @@
+// Command migrate upgrades every stored record to the current schema version. It
+// uses the same STORAGE_BACKEND, DATA_DIR and SQLITE_PATH settings as the API server.
+// Records are also upgraded lazily when the server reads them, so running this is
+// only needed to convert a whole data directory up front.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+
+	"recipe-finder-backend/services"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run migrates the configured store and returns the exit code. It returns rather than
+// exiting so the store is closed, releasing the database or directory lock, either way.
+func run() int {
+	dryRun := flag.Bool("dry-run", false, "report records that need upgrading without rewriting them")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Warning: .env file not found: %v", err)
+	}
+
+	store, err := services.NewStoreFromEnv()
+	if err != nil {
+		log.Printf("Failed to open storage: %v", err)
+		return 1
+	}
+	defer store.Close()
+
+	fmt.Printf("🗄️  Migrating %s\n", store.Name())
+	report, err := services.MigrateStore(store, *dryRun)
+	if err != nil {
+		log.Printf("Migration failed: %v", err)
+		return 1
+	}
+
+	failed := 0
+	for _, kind := range services.AllRecordKinds {
+		fmt.Printf("   %-15s checked %d, upgraded %d, failed %d (schema version %d)\n",
+			kind, report.Checked[kind], report.Migrated[kind], report.Failed[kind], services.SchemaVersion(kind))
+		failed += report.Failed[kind]
+	}
+	if *dryRun {
+		fmt.Println("📝 Dry run: no records were rewritten")
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,471 @@
+// Package fakespoonacular provides a local stand-in for the Spoonacular API.
+//
+// It serves the handful of endpoints used by services.SpoonacularService from
+// fixture files so the backend can be developed and tested without network
+// access or API points. Point the backend at it with SPOONACULAR_BASE_URL.
+package fakespoonacular
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDailyLimit is the daily point allowance of Spoonacular's free plan
+const DefaultDailyLimit = 150
+
+//go:embed fixtures
+var embeddedFixtures embed.FS
+
+// Server is an http.Handler that mimics the Spoonacular API
+type Server struct {
+	recipes     map[int]json.RawMessage
+	summaries   []recipeFixture
+	ingredients []ingredientFixture
+
+	callsMux   sync.Mutex
+	calls      map[string]int
+	dailyLimit float64
+	pointsUsed float64
+	failCount  int
+	failStatus int
+	latency    time.Duration
+}
+
+// recipeFixture holds the parts of a recipe fixture needed to answer search requests
+type recipeFixture struct {
+	ID                  int                `json:"id"`
+	Title               string             `json:"title"`
+	Image               string             `json:"image"`
+	ImageType           string             `json:"imageType"`
+	ExtendedIngredients []ingredientDetail `json:"extendedIngredients"`
+}
+
+// ingredientDetail mirrors an entry of extendedIngredients in a recipe fixture
+type ingredientDetail struct {
+	ID           int      `json:"id"`
+	Amount       float64  `json:"amount"`
+	Unit         string   `json:"unit"`
+	UnitLong     string   `json:"unitLong"`
+	UnitShort    string   `json:"unitShort"`
+	Aisle        string   `json:"aisle"`
+	Name         string   `json:"name"`
+	Original     string   `json:"original"`
+	OriginalName string   `json:"originalName"`
+	Meta         []string `json:"meta"`
+	Image        string   `json:"image"`
+}
+
+// ingredientFixture is a single entry of fixtures/ingredients.json
+type ingredientFixture struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+// New creates a server backed by the fixtures bundled with this package
+func New() (*Server, error) {
+	fixtures, err := fs.Sub(embeddedFixtures, "fixtures")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundled fixtures: %v", err)
+	}
+	return NewFromFS(fixtures)
+}
+
+// NewFromFS creates a server from a fixture tree containing ingredients.json
+// and one recipes/{id}.json file per recipe in /recipes/{id}/information format
+func NewFromFS(fixtures fs.FS) (*Server, error) {
+	s := &Server{
+		recipes:    make(map[int]json.RawMessage),
+		calls:      make(map[string]int),
+		dailyLimit: DefaultDailyLimit,
+	}
+
+	recipeFiles, err := fs.Glob(fixtures, "recipes/*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recipe fixtures: %v", err)
+	}
+	for _, name := range recipeFiles {
+		data, err := fs.ReadFile(fixtures, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read recipe fixture %s: %v", name, err)
+		}
+		var summary recipeFixture
+		if err := json.Unmarshal(data, &summary); err != nil {
+			return nil, fmt.Errorf("failed to parse recipe fixture %s: %v", name, err)
+		}
+		s.recipes[summary.ID] = json.RawMessage(data)
+		s.summaries = append(s.summaries, summary)
+	}
+	sort.Slice(s.summaries, func(i, j int) bool {
+		return s.summaries[i].ID < s.summaries[j].ID
+	})
+
+	if data, err := fs.ReadFile(fixtures, "ingredients.json"); err == nil {
+		if err := json.Unmarshal(data, &s.ingredients); err != nil {
+			return nil, fmt.Errorf("failed to parse ingredient fixtures: %v", err)
+		}
+	}
+
+	return s, nil
+}
+
+// Start serves the fixtures on a loopback listener; callers must Close the returned server
+func (s *Server) Start() *httptest.Server {
+	return httptest.NewServer(s)
+}
+
+// Calls returns how many requests the named endpoint has answered, for example
+// "findByIngredients", "random", "information", "informationBulk" or "ingredients"
+func (s *Server) Calls(endpoint string) int {
+	s.callsMux.Lock()
+	defer s.callsMux.Unlock()
+	return s.calls[endpoint]
+}
+
+// SetDailyLimit changes the point allowance; once it is spent every request fails with 402
+func (s *Server) SetDailyLimit(points float64) {
+	s.callsMux.Lock()
+	defer s.callsMux.Unlock()
+	s.dailyLimit = points
+}
+
+// PointsUsed returns how many quota points the answered requests have cost
+func (s *Server) PointsUsed() float64 {
+	s.callsMux.Lock()
+	defer s.callsMux.Unlock()
+	return s.pointsUsed
+}
+
+// FailNext makes the next count requests fail with status, to exercise retries and the
+// circuit breaker. 429 responses carry a Retry-After of one second.
+func (s *Server) FailNext(count int, status int) {
+	s.callsMux.Lock()
+	defer s.callsMux.Unlock()
+	s.failCount = count
+	s.failStatus = status
+}
+
+// SetLatency delays every response by d, to exercise timeouts and cancellation
+func (s *Server) SetLatency(d time.Duration) {
+	s.callsMux.Lock()
+	defer s.callsMux.Unlock()
+	s.latency = d
+}
+
+// delay waits out the configured latency, reporting false if the client gave up first
+func (s *Server) delay(r *http.Request) bool {
+	s.callsMux.Lock()
+	latency := s.latency
+	s.callsMux.Unlock()
+	if latency <= 0 {
+		return true
+	}
+
+	select {
+	case <-time.After(latency):
+		return true
+	case <-r.Context().Done():
+		fmt.Printf("🚫 Client gave up on %s during injected latency\n", r.URL.Path)
+		return false
+	}
+}
+
+// injectedFailure consumes one pending failure, returning its status or 0
+func (s *Server) injectedFailure() int {
+	s.callsMux.Lock()
+	defer s.callsMux.Unlock()
+	if s.failCount <= 0 {
+		return 0
+	}
+	s.failCount--
+	return s.failStatus
+}
+
+// ServeHTTP routes a request to the matching Spoonacular endpoint
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if r.URL.Query().Get("apiKey") == "" {
+		writeError(w, http.StatusUnauthorized, "You are not authorized. Please read https://spoonacular.com/food-api/docs#Authentication")
+		return
+	}
+
+	if !s.delay(r) {
+		return
+	}
+
+	if status := s.injectedFailure(); status != 0 {
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "1")
+		}
+		writeError(w, status, http.StatusText(status))
+		return
+	}
+
+	p := path.Clean(r.URL.Path)
+	switch {
+	case p == "/recipes/findByIngredients":
+		s.count("findByIngredients")
+		s.findByIngredients(w, r)
+	case p == "/recipes/random":
+		s.count("random")
+		s.random(w, r)
+	case p == "/food/ingredients/search":
+		s.count("ingredients")
+		s.searchIngredients(w, r)
+	case p == "/recipes/informationBulk":
+		s.count("informationBulk")
+		s.informationBulk(w, r)
+	case strings.HasPrefix(p, "/recipes/") && strings.HasSuffix(p, "/information"):
+		s.count("information")
+		s.information(w, strings.TrimSuffix(strings.TrimPrefix(p, "/recipes/"), "/information"))
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+// count records a call to an endpoint
+func (s *Server) count(endpoint string) {
+	s.callsMux.Lock()
+	defer s.callsMux.Unlock()
+	s.calls[endpoint]++
+}
+
+// charge spends quota points on a request and sets Spoonacular's quota headers. Once the
+// daily limit is reached it writes a 402 response and returns false.
+func (s *Server) charge(w http.ResponseWriter, points float64) bool {
+	s.callsMux.Lock()
+	if s.pointsUsed >= s.dailyLimit {
+		s.callsMux.Unlock()
+		writeError(w, http.StatusPaymentRequired, fmt.Sprintf("Your daily points limit of %g has been reached. Please upgrade your plan to continue using the API.", s.dailyLimit))
+		return false
+	}
+	s.pointsUsed += points
+	used, left := s.pointsUsed, s.dailyLimit-s.pointsUsed
+	s.callsMux.Unlock()
+
+	if left < 0 {
+		left = 0
+	}
+	w.Header().Set("X-API-Quota-Request", strconv.FormatFloat(points, 'f', -1, 64))
+	w.Header().Set("X-API-Quota-Used", strconv.FormatFloat(used, 'f', -1, 64))
+	w.Header().Set("X-API-Quota-Left", strconv.FormatFloat(left, 'f', -1, 64))
+	return true
+}
+
+// findByIngredients answers /recipes/findByIngredients
+func (s *Server) findByIngredients(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var wanted []string
+	for _, ingredient := range strings.Split(query.Get("ingredients"), ",") {
+		if trimmed := strings.ToLower(strings.TrimSpace(ingredient)); trimmed != "" {
+			wanted = append(wanted, trimmed)
+		}
+	}
+	number := intParam(query.Get("number"), 10)
+	ranking := intParam(query.Get("ranking"), 1)
+
+	type searchResult struct {
+		ID                    int                `json:"id"`
+		Title                 string             `json:"title"`
+		Image                 string             `json:"image"`
+		ImageType             string             `json:"imageType"`
+		UsedIngredientCount   int                `json:"usedIngredientCount"`
+		MissedIngredientCount int                `json:"missedIngredientCount"`
+		MissedIngredients     []ingredientDetail `json:"missedIngredients"`
+		UsedIngredients       []ingredientDetail `json:"usedIngredients"`
+		UnusedIngredients     []ingredientDetail `json:"unusedIngredients"`
+		Likes                 int                `json:"likes"`
+	}
+
+	results := make([]searchResult, 0)
+	for _, recipe := range s.summaries {
+		result := searchResult{
+			ID:                recipe.ID,
+			Title:             recipe.Title,
+			Image:             recipe.Image,
+			ImageType:         recipe.ImageType,
+			MissedIngredients: []ingredientDetail{},
+			UsedIngredients:   []ingredientDetail{},
+			UnusedIngredients: []ingredientDetail{},
+			Likes:             recipe.ID % 97,
+		}
+		usedWanted := make(map[string]bool)
+		for _, ingredient := range recipe.ExtendedIngredients {
+			matched := false
+			for _, w := range wanted {
+				if ingredientMatches(w, ingredient.Name) {
+					usedWanted[w] = true
+					matched = true
+				}
+			}
+			if matched {
+				result.UsedIngredients = append(result.UsedIngredients, ingredient)
+			} else {
+				result.MissedIngredients = append(result.MissedIngredients, ingredient)
+			}
+		}
+		if len(result.UsedIngredients) == 0 {
+			continue
+		}
+		for _, w := range wanted {
+			if !usedWanted[w] {
+				result.UnusedIngredients = append(result.UnusedIngredients, ingredientDetail{Name: w, Original: w, OriginalName: w, Meta: []string{}})
+			}
+		}
+		result.UsedIngredientCount = len(result.UsedIngredients)
+		result.MissedIngredientCount = len(result.MissedIngredients)
+		results = append(results, result)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if ranking == 2 {
+			if a.MissedIngredientCount != b.MissedIngredientCount {
+				return a.MissedIngredientCount < b.MissedIngredientCount
+			}
+			return a.UsedIngredientCount > b.UsedIngredientCount
+		}
+		if a.UsedIngredientCount != b.UsedIngredientCount {
+			return a.UsedIngredientCount > b.UsedIngredientCount
+		}
+		return a.MissedIngredientCount < b.MissedIngredientCount
+	})
+	if len(results) > number {
+		results = results[:number]
+	}
+
+	if !s.charge(w, 1+0.01*float64(len(results))) {
+		return
+	}
+	writeJSON(w, results)
+}
+
+// random answers /recipes/random with the first fixtures in ID order
+func (s *Server) random(w http.ResponseWriter, r *http.Request) {
+	number := intParam(r.URL.Query().Get("number"), 1)
+	recipes := make([]json.RawMessage, 0, number)
+	for _, recipe := range s.summaries {
+		if len(recipes) >= number {
+			break
+		}
+		recipes = append(recipes, s.recipes[recipe.ID])
+	}
+	if !s.charge(w, 1+0.01*float64(len(recipes))) {
+		return
+	}
+	writeJSON(w, map[string]interface{}{"recipes": recipes})
+}
+
+// information answers /recipes/{id}/information
+func (s *Server) information(w http.ResponseWriter, rawID string) {
+	id, err := strconv.Atoi(rawID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid recipe id %q", rawID))
+		return
+	}
+	recipe, ok := s.recipes[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("A recipe with the id %d does not exist.", id))
+		return
+	}
+	if !s.charge(w, 1) {
+		return
+	}
+	writeJSON(w, recipe)
+}
+
+// informationBulk answers /recipes/informationBulk; unknown IDs are left out, as
+// Spoonacular does
+func (s *Server) informationBulk(w http.ResponseWriter, r *http.Request) {
+	recipes := make([]json.RawMessage, 0)
+	for _, rawID := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(rawID))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid recipe id %q", rawID))
+			return
+		}
+		if recipe, ok := s.recipes[id]; ok {
+			recipes = append(recipes, recipe)
+		}
+	}
+
+	points := 1.0
+	if len(recipes) > 1 {
+		points += 0.5 * float64(len(recipes)-1)
+	}
+	if !s.charge(w, points) {
+		return
+	}
+	writeJSON(w, recipes)
+}
+
+// searchIngredients answers /food/ingredients/search
+func (s *Server) searchIngredients(w http.ResponseWriter, r *http.Request) {
+	query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("query")))
+	number := intParam(r.URL.Query().Get("number"), 10)
+
+	results := make([]ingredientFixture, 0)
+	for _, ingredient := range s.ingredients {
+		if len(results) >= number {
+			break
+		}
+		if query != "" && strings.Contains(ingredient.Name, query) {
+			results = append(results, ingredient)
+		}
+	}
+
+	if !s.charge(w, 1+0.01*float64(len(results))) {
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"results":      results,
+		"offset":       0,
+		"number":       number,
+		"totalResults": len(results),
+	})
+}
+
+// ingredientMatches reports whether a searched ingredient matches a recipe ingredient name
+func ingredientMatches(wanted, name string) bool {
+	name = strings.ToLower(name)
+	wanted = strings.TrimSuffix(wanted, "s")
+	return strings.Contains(name, wanted)
+}
+
+// intParam parses a positive integer query parameter with a fallback
+func intParam(value string, fallback int) int {
+	if n, err := strconv.Atoi(value); err == nil && n > 0 {
+		return n
+	}
+	return fallback
+}
+
+// writeJSON writes a 200 response with a JSON body
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeError writes an error body shaped like Spoonacular's
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "failure",
+		"code":    status,
+		"message": message,
+	})
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"recipe-finder-backend/models"
+	"recipe-finder-backend/services"
+)
+
+// Error codes returned in the "code" field of error responses. They are part of the
+// API: clients branch on them, so existing codes must not change meaning.
+const (
+	CodeInvalidRequest      = "invalid_request"       // Malformed body or parameter
+	CodeValidationFailed    = "validation_failed"     // Fields failed validation; details lists them
+	CodeMethodNotAllowed    = "method_not_allowed"    // The route exists but not for this method
+	CodeNotFound            = "not_found"             // No such route
+	CodeRecipeNotFound      = "recipe_not_found"      // No recipe with this ID
+	CodeInvalidRecipeID     = "invalid_recipe_id"     // The recipe ID is malformed
+	CodePantryItemNotFound  = "pantry_item_not_found" // No pantry item with this ID
+	CodePantryEmpty         = "pantry_empty"          // The pantry has nothing to search with
+	CodeQuotaExhausted      = "quota_exhausted"       // Daily Spoonacular budget spent and nothing cached
+	CodeUpstreamRateLimited = "upstream_rate_limited" // Spoonacular is throttling us
+	CodeUpstreamUnavailable = "upstream_unavailable"  // Spoonacular is failing or the circuit is open
+	CodeUpstreamTimeout     = "upstream_timeout"      // Spoonacular did not answer within REQUEST_TIMEOUT_SECONDS
+	CodeUpstreamRejected    = "upstream_rejected"     // Spoonacular refused the request we sent
+	CodeUpstreamError       = "upstream_error"        // Spoonacular answered with something unexpected, like a 404 for a search
+	CodeRequestCancelled    = "request_cancelled"     // The client went away before the response was ready
+	CodeNotImplemented      = "not_implemented"       // The recipe provider lacks the feature
+	CodeInternal            = "internal_error"        // Anything else
+)
+
+// statusClientClosedRequest is the non-standard status for requests the client
+// abandoned before a response was ready
+const statusClientClosedRequest = 499
+
+// defaultRetryAfter is suggested to clients when Spoonacular throttles us without saying for how long
+const defaultRetryAfter = 60 * time.Second
+
+// ErrorResponse is the JSON body of every error response
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody describes what went wrong
+type ErrorBody struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	RequestID string      `json:"requestId,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// writeError writes an error envelope with the request's ID
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string, details interface{}) {
+	requestID := RequestIDFromContext(r.Context())
+	if status >= http.StatusInternalServerError {
+		fmt.Printf("❌ [%s] %s %s: %d %s\n", requestID, r.Method, r.URL.Path, status, code)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: ErrorBody{
+		Code:      code,
+		Message:   message,
+		RequestID: requestID,
+		Details:   details,
+	}})
+}
+
+// badRequest writes a 400 for a malformed body or parameter
+func badRequest(w http.ResponseWriter, r *http.Request, message string) {
+	writeError(w, r, http.StatusBadRequest, CodeInvalidRequest, message, nil)
+}
+
+// methodNotAllowed writes a 405
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed", nil)
+}
+
+// recipeNotFound writes a 404 for an unknown recipe ID
+func recipeNotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, CodeRecipeNotFound, "Recipe not found", nil)
+}
+
+// pantryItemNotFound writes a 404 for an unknown pantry item ID
+func pantryItemNotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, CodePantryItemNotFound, "Pantry item not found", nil)
+}
+
+// internalError logs err and writes a 500 with a message that doesn't expose it
+func internalError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	if err != nil {
+		fmt.Printf("Error [%s]: %s: %v\n", RequestIDFromContext(r.Context()), message, err)
+	}
+	writeError(w, r, http.StatusInternalServerError, CodeInternal, message, nil)
+}
+
+// validationError writes a 400 for a request that failed validation. Field problems
+// from models.ValidationError are listed in details.
+func validationError(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErr *models.ValidationError
+	if errors.As(err, &validationErr) {
+		writeError(w, r, http.StatusBadRequest, CodeValidationFailed, validationErr.Error(),
+			map[string]interface{}{"fields": validationErr.Fields})
+		return
+	}
+	writeError(w, r, http.StatusBadRequest, CodeValidationFailed, err.Error(), nil)
+}
+
+// NotFound handles requests that match no route
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, CodeNotFound, "No such endpoint", nil)
+}
+
+// MethodNotAllowed handles requests whose route exists for other methods only
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	methodNotAllowed(w, r)
+}
+
+// providerError writes the response for a failed recipe provider call. Upstream
+// failures with nothing cached to fall back on are reported by class rather than as 500.
+// A 404 from Spoonacular only means the recipe is missing on the details routes, which
+// check for it themselves; anywhere else it is an upstream error.
+func (h *RecipeHandler) providerError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	var upstreamErr *services.UpstreamError
+	errors.As(err, &upstreamErr)
+
+	switch {
+	case errors.Is(err, services.ErrRecipeNotFound):
+		recipeNotFound(w, r)
+	case errors.Is(err, services.ErrUpstreamNotFound):
+		fmt.Printf("Error [%s]: %s: %v\n", RequestIDFromContext(r.Context()), message, err)
+		writeError(w, r, http.StatusBadGateway, CodeUpstreamError, "Spoonacular could not serve the request", nil)
+	case errors.Is(err, services.ErrInvalidSearchOptions), errors.Is(err, services.ErrInvalidServings):
+		badRequest(w, r, err.Error())
+	case errors.Is(err, services.ErrInvalidRecipeID):
+		writeError(w, r, http.StatusBadRequest, CodeInvalidRecipeID, err.Error(), nil)
+	case errors.Is(err, services.ErrQuotaExhausted):
+		var details map[string]interface{}
+		if reporter, ok := h.provider.(services.QuotaReporter); ok {
+			resetsAt := reporter.QuotaStatus().ResetsAt
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(resetsAt).Seconds())+1))
+			details = map[string]interface{}{"resetsAt": resetsAt}
+		}
+		writeError(w, r, http.StatusServiceUnavailable, CodeQuotaExhausted,
+			"Daily Spoonacular budget reached; only cached results are available until it resets", details)
+	case errors.Is(err, services.ErrUpstreamRateLimited):
+		retryAfter := defaultRetryAfter
+		if upstreamErr != nil && upstreamErr.RetryAfter > 0 {
+			retryAfter = upstreamErr.RetryAfter
+		}
+		seconds := int((retryAfter + time.Second - 1) / time.Second)
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		writeError(w, r, http.StatusTooManyRequests, CodeUpstreamRateLimited,
+			"Spoonacular is rate limiting requests; try again shortly", map[string]interface{}{"retryAfterSeconds": seconds})
+	case errors.Is(err, services.ErrCircuitOpen), errors.Is(err, services.ErrUpstreamUnavailable):
+		writeError(w, r, http.StatusServiceUnavailable, CodeUpstreamUnavailable,
+			"Spoonacular is unavailable and nothing is cached for this request", nil)
+	case errors.Is(err, services.ErrUpstreamRejected):
+		fmt.Printf("Error [%s]: %s: %v\n", RequestIDFromContext(r.Context()), message, err)
+		writeError(w, r, http.StatusBadGateway, CodeUpstreamRejected, "Spoonacular rejected the request", nil)
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(w, r, http.StatusGatewayTimeout, CodeUpstreamTimeout, "Timed out waiting for Spoonacular", nil)
+	case errors.Is(err, context.Canceled):
+		// The client has gone away; the response only shows up in access logs
+		fmt.Printf("🚫 Request %s cancelled by client: %v\n", RequestIDFromContext(r.Context()), err)
+		writeError(w, r, statusClientClosedRequest, CodeRequestCancelled, "Request cancelled", nil)
+	default:
+		internalError(w, r, message, err)
+	}
+}
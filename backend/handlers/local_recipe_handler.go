@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"recipe-finder-backend/models"
+	"recipe-finder-backend/services"
+)
+
+// ListMyRecipes handles GET /api/v1/my-recipes
+func (h *RecipeHandler) ListMyRecipes(w http.ResponseWriter, r *http.Request) {
+	recipes, err := h.storageService.ListLocalRecipes()
+	if err != nil {
+		internalError(w, r, "Failed to list recipes", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"recipes": recipes,
+		"total":   len(recipes),
+	})
+}
+
+// CreateMyRecipe handles POST /api/v1/my-recipes
+func (h *RecipeHandler) CreateMyRecipe(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateRecipeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, "Invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		validationError(w, r, err)
+		return
+	}
+	if err := services.ValidateIngredientLines(req.Ingredients); err != nil {
+		validationError(w, r, err)
+		return
+	}
+
+	id, err := services.NewLocalRecipeID()
+	if err != nil {
+		internalError(w, r, "Failed to create recipe", err)
+		return
+	}
+
+	recipe := req.NewRecipe(id, time.Now())
+	if err := h.storageService.SaveLocalRecipe(recipe); err != nil {
+		internalError(w, r, "Failed to create recipe", fmt.Errorf("saving local recipe %s: %v", id, err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/my-recipes/"+id)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(recipe)
+}
+
+// GetMyRecipe handles GET /api/v1/my-recipes/{id}
+func (h *RecipeHandler) GetMyRecipe(w http.ResponseWriter, r *http.Request) {
+	recipe, ok := h.loadMyRecipe(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recipe)
+}
+
+// ReplaceMyRecipe handles PUT /api/v1/my-recipes/{id}
+func (h *RecipeHandler) ReplaceMyRecipe(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateRecipeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, "Invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		validationError(w, r, err)
+		return
+	}
+	if err := services.ValidateIngredientLines(req.Ingredients); err != nil {
+		validationError(w, r, err)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	recipe, err := h.storageService.UpdateLocalRecipe(id, func(recipe *models.Recipe) {
+		replaced := req.NewRecipe(recipe.ID, time.Now())
+		replaced.CreatedAt = recipe.CreatedAt
+		*recipe = *replaced
+	})
+	h.writeMyRecipe(w, r, id, recipe, err)
+}
+
+// UpdateMyRecipe handles PATCH /api/v1/my-recipes/{id}
+func (h *RecipeHandler) UpdateMyRecipe(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateRecipeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, "Invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		validationError(w, r, err)
+		return
+	}
+	if req.Ingredients != nil {
+		if err := services.ValidateIngredientLines(*req.Ingredients); err != nil {
+			validationError(w, r, err)
+			return
+		}
+	}
+
+	id := mux.Vars(r)["id"]
+	recipe, err := h.storageService.UpdateLocalRecipe(id, func(recipe *models.Recipe) {
+		req.Apply(recipe, time.Now())
+	})
+	h.writeMyRecipe(w, r, id, recipe, err)
+}
+
+// DeleteMyRecipe handles DELETE /api/v1/my-recipes/{id}
+func (h *RecipeHandler) DeleteMyRecipe(w http.ResponseWriter, r *http.Request) {
+	if err := h.storageService.DeleteLocalRecipe(mux.Vars(r)["id"]); err != nil {
+		if errors.Is(err, services.ErrRecipeNotFound) {
+			recipeNotFound(w, r)
+			return
+		}
+		internalError(w, r, "Failed to delete recipe", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadMyRecipe loads a local recipe, writing an error response and returning false if it cannot
+func (h *RecipeHandler) loadMyRecipe(w http.ResponseWriter, r *http.Request, id string) (*models.Recipe, bool) {
+	recipe, err := h.storageService.LoadLocalRecipe(id)
+	if err != nil {
+		if errors.Is(err, services.ErrRecipeNotFound) {
+			recipeNotFound(w, r)
+			return nil, false
+		}
+		internalError(w, r, "Failed to load recipe", fmt.Errorf("loading local recipe %s: %v", id, err))
+		return nil, false
+	}
+	return recipe, true
+}
+
+// writeMyRecipe writes the result of changing a local recipe as the response
+func (h *RecipeHandler) writeMyRecipe(w http.ResponseWriter, r *http.Request, id string, recipe *models.Recipe, err error) {
+	if err != nil {
+		if errors.Is(err, services.ErrRecipeNotFound) {
+			recipeNotFound(w, r)
+			return
+		}
+		internalError(w, r, "Failed to save recipe", fmt.Errorf("saving local recipe %s: %v", id, err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recipe)
+}
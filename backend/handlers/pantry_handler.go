@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"recipe-finder-backend/models"
+	"recipe-finder-backend/services"
+)
+
+// CookRecipeRequest represents the optional request body for marking a recipe as cooked
+type CookRecipeRequest struct {
+	Servings int `json:"servings"` // The recipe's own servings if 0
+}
+
+// ListPantry handles GET /api/v1/pantry
+func (h *RecipeHandler) ListPantry(w http.ResponseWriter, r *http.Request) {
+	items, err := h.storageService.ListPantryItems()
+	if err != nil {
+		internalError(w, r, "Failed to list pantry items", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items": items,
+		"total": len(items),
+	})
+}
+
+// CreatePantryItem handles POST /api/v1/pantry
+func (h *RecipeHandler) CreatePantryItem(w http.ResponseWriter, r *http.Request) {
+	var req models.CreatePantryItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, "Invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		validationError(w, r, err)
+		return
+	}
+
+	id, err := services.NewPantryItemID()
+	if err != nil {
+		internalError(w, r, "Failed to create pantry item", err)
+		return
+	}
+
+	item := req.NewPantryItem(id, time.Now())
+	if err := h.storageService.SavePantryItem(item); err != nil {
+		internalError(w, r, "Failed to create pantry item", fmt.Errorf("saving pantry item %s: %v", id, err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/pantry/"+id)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(item)
+}
+
+// GetPantryItem handles GET /api/v1/pantry/{id}
+func (h *RecipeHandler) GetPantryItem(w http.ResponseWriter, r *http.Request) {
+	item, ok := h.loadPantryItem(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// ReplacePantryItem handles PUT /api/v1/pantry/{id}
+func (h *RecipeHandler) ReplacePantryItem(w http.ResponseWriter, r *http.Request) {
+	var req models.CreatePantryItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, "Invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		validationError(w, r, err)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	item, err := h.storageService.UpdatePantryItem(id, func(item *models.PantryItem) {
+		replaced := req.NewPantryItem(item.ID, time.Now())
+		replaced.CreatedAt = item.CreatedAt
+		*item = *replaced
+	})
+	h.writePantryItem(w, r, id, item, err)
+}
+
+// UpdatePantryItem handles PATCH /api/v1/pantry/{id}
+func (h *RecipeHandler) UpdatePantryItem(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdatePantryItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, "Invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		validationError(w, r, err)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	item, err := h.storageService.UpdatePantryItem(id, func(item *models.PantryItem) {
+		req.Apply(item, time.Now())
+	})
+	h.writePantryItem(w, r, id, item, err)
+}
+
+// DeletePantryItem handles DELETE /api/v1/pantry/{id}
+func (h *RecipeHandler) DeletePantryItem(w http.ResponseWriter, r *http.Request) {
+	if err := h.storageService.DeletePantryItem(mux.Vars(r)["id"]); err != nil {
+		if errors.Is(err, services.ErrPantryItemNotFound) {
+			pantryItemNotFound(w, r)
+			return
+		}
+		internalError(w, r, "Failed to delete pantry item", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SearchPantryRecipes handles GET /api/v1/pantry/recipes: a recipe search by the
+// ingredients in the pantry. Items past their expiry date are left out, and unless
+// another sort is asked for, recipes using up items closest to expiry come first.
+// Pantry staples like salt and flour only count if they are in the pantry.
+func (h *RecipeHandler) SearchPantryRecipes(w http.ResponseWriter, r *http.Request) {
+	var req RecipeSearchRequest
+	if !readPageParams(w, r, &req) {
+		return
+	}
+
+	items, err := h.storageService.ListPantryItems()
+	if err != nil {
+		internalError(w, r, "Failed to list pantry items", err)
+		return
+	}
+	search := services.PlanPantrySearch(items, time.Now())
+	if len(search.Ingredients) == 0 {
+		writeError(w, r, http.StatusConflict, CodePantryEmpty, "The pantry has no unexpired items to search with",
+			map[string]interface{}{"expired": search.Expired})
+		return
+	}
+	if req.Sort == "" && req.Ranking == 0 {
+		req.Sort = string(services.SortExpiring)
+	}
+
+	ctx, cancel := h.providerContext(r)
+	defer cancel()
+	page, err := h.provider.SearchRecipesPageContext(ctx, services.RecipeSearchOptions{
+		Ingredients: search.Ingredients,
+		Limit:       req.Limit,
+		Offset:      req.Offset,
+		Sort:        services.RecipeSort(req.Sort),
+		Ranking:     req.Ranking,
+		Staples:     true,
+		Urgency:     search.Urgency,
+	})
+	if err != nil {
+		h.providerError(w, r, err, "Failed to fetch recipes")
+		return
+	}
+
+	response := recipePageResponse(page)
+	response["expiring"] = search.Expiring
+	response["expired"] = search.Expired
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CookRecipe handles POST /api/v1/recipes/{id}/cooked: the recipe's ingredients, scaled
+// to the servings in the optional body, are taken out of the pantry
+func (h *RecipeHandler) CookRecipe(w http.ResponseWriter, r *http.Request) {
+	var req CookRecipeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		badRequest(w, r, "Invalid request body")
+		return
+	}
+	if req.Servings < 0 {
+		validationError(w, r, &models.ValidationError{Fields: map[string]string{"servings": "must not be negative"}})
+		return
+	}
+
+	recipeID, err := services.ParseRecipeID(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidRecipeID, err.Error(), nil)
+		return
+	}
+
+	ctx, cancel := h.providerContext(r)
+	defer cancel()
+	details, ok := h.loadRecipeDetails(ctx, w, r, recipeID)
+	if !ok {
+		return
+	}
+	if req.Servings > 0 {
+		if details, err = services.ScaleRecipeDetails(details, req.Servings); err != nil {
+			h.providerError(w, r, err, "Failed to scale recipe")
+			return
+		}
+	}
+
+	usage, err := h.storageService.UsePantryIngredients(details)
+	if err != nil {
+		internalError(w, r, "Failed to update pantry", fmt.Errorf("cooking recipe %s: %v", details.ID, err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"recipe":      map[string]interface{}{"id": details.ID, "title": details.Title, "servings": details.Servings},
+		"used":        usage.Used,
+		"notInPantry": usage.NotInPantry,
+	})
+}
+
+// loadPantryItem loads a pantry item, writing an error response and returning false if it cannot
+func (h *RecipeHandler) loadPantryItem(w http.ResponseWriter, r *http.Request, id string) (*models.PantryItem, bool) {
+	item, err := h.storageService.LoadPantryItem(id)
+	if err != nil {
+		if errors.Is(err, services.ErrPantryItemNotFound) {
+			pantryItemNotFound(w, r)
+			return nil, false
+		}
+		internalError(w, r, "Failed to load pantry item", fmt.Errorf("loading pantry item %s: %v", id, err))
+		return nil, false
+	}
+	return item, true
+}
+
+// writePantryItem writes an updated pantry item as the response, or the error updating it
+func (h *RecipeHandler) writePantryItem(w http.ResponseWriter, r *http.Request, id string, item *models.PantryItem, err error) {
+	if err != nil {
+		if errors.Is(err, services.ErrPantryItemNotFound) {
+			pantryItemNotFound(w, r)
+			return
+		}
+		internalError(w, r, "Failed to save pantry item", fmt.Errorf("saving pantry item %s: %v", id, err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
@@ -1,29 +1,44 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
+	"recipe-finder-backend/models"
 	"recipe-finder-backend/services"
+	"recipe-finder-backend/units"
 	"github.com/gorilla/mux"
 )
 
 // RecipeHandler handles recipe-related HTTP requests
 type RecipeHandler struct{
-	spoonacularService *services.SpoonacularService
-	storageService     *services.StorageService
+	provider       services.RecipeProvider
+	storageService *services.StorageService
+	searchService  *services.SearchService
+	requestTimeout time.Duration
 }
 
-// NewRecipeHandler creates a new recipe handler
-func NewRecipeHandler() *RecipeHandler {
+// NewRecipeHandler creates a new recipe handler that serves recipes from the given provider
+func NewRecipeHandler(provider services.RecipeProvider, storage *services.StorageService) *RecipeHandler {
 	return &RecipeHandler{
-		spoonacularService: services.NewSpoonacularService(),
-		storageService:     services.NewStorageService(),
+		provider:       provider,
+		storageService: storage,
+		searchService:  services.NewSearchService(storage),
+		requestTimeout: services.GetRequestTimeout(),
 	}
 }
 
+// providerContext returns the context for a recipe provider call: it ends when the client
+// disconnects or the request has waited REQUEST_TIMEOUT_SECONDS
+func (h *RecipeHandler) providerContext(r *http.Request) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(r.Context(), h.requestTimeout)
+}
+
 // HealthCheck handles GET /api/v1/health
 func (h *RecipeHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -41,6 +56,10 @@ func (h *RecipeHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 // RecipeSearchRequest represents the request body for recipe search
 type RecipeSearchRequest struct {
 	Ingredients []string `json:"ingredients"`
+	Limit       int      `json:"limit"`
+	Offset      int      `json:"offset"`
+	Sort        string   `json:"sort"`    // match, missing, time, health or price
+	Ranking     int      `json:"ranking"` // 1 maximizes used ingredients, 2 minimizes missing ones
 }
 
 // Recipe represents a recipe in the response
@@ -64,67 +83,102 @@ func (h *RecipeHandler) GetRecipesByIngredients(w http.ResponseWriter, r *http.R
 		return
 	}
 	
-	var ingredients []string
+	var req RecipeSearchRequest
 	
 	if r.Method == http.MethodGet {
 		// Handle GET request with query parameters
-		ingredientsParam := r.URL.Query().Get("ingredients")
+		params := r.URL.Query()
+		ingredientsParam := params.Get("ingredients")
 		if ingredientsParam != "" {
 			// Split comma-separated ingredients
-			ingredients = strings.Split(ingredientsParam, ",")
+			req.Ingredients = strings.Split(ingredientsParam, ",")
 			// Trim whitespace from each ingredient
-			for i, ingredient := range ingredients {
-				ingredients[i] = strings.TrimSpace(ingredient)
+			for i, ingredient := range req.Ingredients {
+				req.Ingredients[i] = strings.TrimSpace(ingredient)
 			}
 		}
+		if !readPageParams(w, r, &req) {
+			return
+		}
 	} else if r.Method == http.MethodPost {
 		// Handle POST request with JSON body
-		var req RecipeSearchRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			badRequest(w, r, "Invalid request body")
 			return
 		}
-		ingredients = req.Ingredients
 	} else {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		methodNotAllowed(w, r)
 		return
 	}
 
-	// Use Spoonacular service to get recipes
-	recipes, err := h.spoonacularService.SearchRecipesByIngredients(ingredients)
+	// Use the recipe provider to get one sorted page of recipes
+	ctx, cancel := h.providerContext(r)
+	defer cancel()
+	page, err := h.provider.SearchRecipesPageContext(ctx, services.RecipeSearchOptions{
+		Ingredients: req.Ingredients,
+		Limit:       req.Limit,
+		Offset:      req.Offset,
+		Sort:        services.RecipeSort(req.Sort),
+		Ranking:     req.Ranking,
+	})
 	if err != nil {
 		// Log the error but don't expose internal details to client
-		http.Error(w, "Failed to fetch recipes", http.StatusInternalServerError)
+		h.providerError(w, r, err, "Failed to fetch recipes")
 		return
 	}
 
-	// If user provided ingredients, recalculate match counts for better accuracy
-	if len(ingredients) > 0 {
-		for i := range recipes {
-			recipes[i].MatchCount = h.spoonacularService.CalculateMatchCount(ingredients, recipes[i].Ingredients)
-		}
-	}
-
 	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recipePageResponse(page))
+}
+
+// readPageParams reads the sort, limit, offset and ranking query parameters of a recipe
+// search, writing an error response and returning false if one is malformed
+func readPageParams(w http.ResponseWriter, r *http.Request, req *RecipeSearchRequest) bool {
+	req.Sort = r.URL.Query().Get("sort")
+	return readIntParams(w, r, []intParam{
+		{"limit", &req.Limit},
+		{"offset", &req.Offset},
+		{"ranking", &req.Ranking},
+	})
+}
+
+// recipePageResponse is the JSON body of a page of recipe search results. It echoes the
+// canonical ingredients the search actually used.
+func recipePageResponse(page *services.RecipePage) map[string]interface{} {
 	response := map[string]interface{}{
-		"recipes":     recipes,
-		"total":       len(recipes),
-		"ingredients": ingredients,
+		"recipes":     page.Recipes,
+		"total":       page.Total,
+		"ingredients": page.Ingredients,
+		"limit":       page.Limit,
+		"offset":      page.Offset,
+		"sort":        page.Sort,
+		"ranking":     page.Ranking,
+		"hasMore":     page.HasMore,
 	}
-	
-	json.NewEncoder(w).Encode(response)
+	if page.HasMore {
+		response["nextOffset"] = page.Offset + len(page.Recipes)
+	}
+	if page.Unsorted > 0 {
+		// Their details weren't at hand, so they sort last whatever their time, health or price
+		response["unsorted"] = page.Unsorted
+	}
+	if page.Stale {
+		// Served from storage past its soft TTL because a refresh is pending or Spoonacular is unavailable
+		response["stale"] = true
+	}
+	return response
 }
 
 // GetStorageStats handles GET /api/v1/storage/stats
 func (h *RecipeHandler) GetStorageStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		methodNotAllowed(w, r)
 		return
 	}
 
 	stats, err := h.storageService.GetStorageStats()
 	if err != nil {
-		http.Error(w, "Failed to get storage stats", http.StatusInternalServerError)
+		internalError(w, r, "Failed to get storage stats", err)
 		return
 	}
 
@@ -132,15 +186,39 @@ func (h *RecipeHandler) GetStorageStats(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(stats)
 }
 
+// GetQuotaStatus handles GET /api/v1/admin/quota
+func (h *RecipeHandler) GetQuotaStatus(w http.ResponseWriter, r *http.Request) {
+	reporter, ok := h.provider.(services.QuotaReporter)
+	if !ok {
+		writeError(w, r, http.StatusNotImplemented, CodeNotImplemented, "Quota tracking not available for this recipe provider", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reporter.QuotaStatus())
+}
+
+// GetCacheStats handles GET /api/v1/cache/stats
+func (h *RecipeHandler) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	reporter, ok := h.provider.(services.CacheReporter)
+	if !ok {
+		writeError(w, r, http.StatusNotImplemented, CodeNotImplemented, "Cache stats not available for this recipe provider", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reporter.CacheStats())
+}
+
 // CreateFilenameMapping handles POST /api/v1/storage/mapping
 func (h *RecipeHandler) CreateFilenameMapping(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		methodNotAllowed(w, r)
 		return
 	}
 
 	if err := h.storageService.CreateFilenameMapping(); err != nil {
-		http.Error(w, "Failed to create filename mapping", http.StatusInternalServerError)
+		internalError(w, r, "Failed to create filename mapping", err)
 		return
 	}
 
@@ -155,7 +233,7 @@ func (h *RecipeHandler) CreateFilenameMapping(w http.ResponseWriter, r *http.Req
 // SearchIngredients handles GET /api/v1/ingredients/search
 func (h *RecipeHandler) SearchIngredients(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		methodNotAllowed(w, r)
 		return
 	}
 
@@ -171,9 +249,11 @@ func (h *RecipeHandler) SearchIngredients(w http.ResponseWriter, r *http.Request
 	}
 
 	// Search for ingredients
-	ingredients, err := h.spoonacularService.SearchIngredients(query)
+	ctx, cancel := h.providerContext(r)
+	defer cancel()
+	ingredients, err := h.provider.SearchIngredientsContext(ctx, query)
 	if err != nil {
-		http.Error(w, "Failed to search ingredients", http.StatusInternalServerError)
+		h.providerError(w, r, err, "Failed to search ingredients")
 		return
 	}
 
@@ -184,13 +264,16 @@ func (h *RecipeHandler) SearchIngredients(w http.ResponseWriter, r *http.Request
 		"query":       query,
 		"total":       len(ingredients),
 	}
+	if len(ingredients) > 0 && ingredients[0].Stale {
+		response["stale"] = true
+	}
 	json.NewEncoder(w).Encode(response)
 }
 
 // SearchRecipes handles GET /api/v1/search/recipes
 func (h *RecipeHandler) SearchRecipes(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		methodNotAllowed(w, r)
 		return
 	}
 
@@ -207,9 +290,11 @@ func (h *RecipeHandler) SearchRecipes(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Search for recipes using the existing service but filter by title
-	recipes, err := h.spoonacularService.SearchRecipesByIngredients([]string{query})
+	ctx, cancel := h.providerContext(r)
+	defer cancel()
+	recipes, err := h.provider.SearchRecipesByIngredientsContext(ctx, []string{query})
 	if err != nil {
-		http.Error(w, "Failed to search recipes", http.StatusInternalServerError)
+		h.providerError(w, r, err, "Failed to search recipes")
 		return
 	}
 
@@ -247,6 +332,82 @@ func (h *RecipeHandler) SearchRecipes(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// Search handles GET and POST /api/v1/search
+func (h *RecipeHandler) Search(w http.ResponseWriter, r *http.Request) {
+	var req models.SearchRecipeRequest
+
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			badRequest(w, r, "Invalid request body")
+			return
+		}
+	} else {
+		params := r.URL.Query()
+		req.Query = params.Get("q")
+		req.Category = params.Get("category")
+		req.Difficulty = params.Get("difficulty")
+		for _, tags := range params["tags"] {
+			for _, tag := range strings.Split(tags, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					req.Tags = append(req.Tags, tag)
+				}
+			}
+		}
+
+		if !readIntParams(w, r, []intParam{
+			{"max_prep_time", &req.MaxPrepTime},
+			{"max_cook_time", &req.MaxCookTime},
+			{"limit", &req.Limit},
+			{"offset", &req.Offset},
+		}) {
+			return
+		}
+	}
+
+	page, err := h.searchService.Search(req)
+	if err != nil {
+		if _, ok := err.(*models.ValidationError); ok {
+			validationError(w, r, err)
+			return
+		}
+		internalError(w, r, "Failed to search recipes", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]interface{}{
+		"recipes": page.Results,
+		"total":   page.Total,
+		"limit":   page.Limit,
+		"offset":  page.Offset,
+		"query":   req.Query,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// intParam is a whole-number query parameter and where to store it
+type intParam struct {
+	name   string
+	target *int
+}
+
+// readIntParams reads whole-number query parameters in order, writing an error response
+// for the first malformed one and returning false
+func readIntParams(w http.ResponseWriter, r *http.Request, params []intParam) bool {
+	query := r.URL.Query()
+	for _, param := range params {
+		if value := query.Get(param.name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				badRequest(w, r, fmt.Sprintf("Invalid %s: must be a whole number", param.name))
+				return false
+			}
+			*param.target = n
+		}
+	}
+	return true
+}
+
 // Helper function to check if a string contains another string (case insensitive)
 func containsIgnoreCase(s, substr string) bool {
 	return len(s) >= len(substr) && 
@@ -262,29 +423,76 @@ func (h *RecipeHandler) GetRecipeDetails(w http.ResponseWriter, r *http.Request)
 	fmt.Printf("🔍 Recipe details request: %s %s\n", r.Method, r.URL.Path)
 	
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		methodNotAllowed(w, r)
 		return
 	}
 
-	// Extract recipe ID from URL using gorilla/mux
+	// Extract recipe ID from URL using gorilla/mux and reject malformed IDs before any lookup
 	vars := mux.Vars(r)
-	recipeID := vars["id"]
-	fmt.Printf("🔑 Extracted recipe ID: '%s'\n", recipeID)
-	
-	if recipeID == "" {
-		http.Error(w, "Recipe ID is required", http.StatusBadRequest)
+	recipeID, err := services.ParseRecipeID(vars["id"])
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidRecipeID, err.Error(), nil)
 		return
 	}
+	fmt.Printf("🔑 Extracted recipe ID: '%s'\n", recipeID)
 
-	// Get recipe details from Spoonacular service
-	recipeDetails, err := h.spoonacularService.GetRecipeDetails(recipeID)
+	system, err := units.ParseSystem(r.URL.Query().Get("units"))
 	if err != nil {
-		// Log the error but don't expose internal details to client
-		fmt.Printf("Error fetching recipe details for ID %s: %v\n", recipeID, err)
-		http.Error(w, "Failed to fetch recipe details", http.StatusInternalServerError)
+		badRequest(w, r, err.Error())
 		return
 	}
+	servings := 0
+	servingsParam := r.URL.Query().Get("servings")
+	if servingsParam != "" {
+		if servings, err = strconv.Atoi(servingsParam); err != nil {
+			badRequest(w, r, "servings must be a whole number")
+			return
+		}
+	}
+
+	ctx, cancel := h.providerContext(r)
+	defer cancel()
+	recipeDetails, ok := h.loadRecipeDetails(ctx, w, r, recipeID)
+	if !ok {
+		return
+	}
+
+	// Convert to the measurement system and scale to the servings asked for
+	recipeDetails = services.ConvertRecipeDetails(recipeDetails, system)
+	if servingsParam != "" {
+		if recipeDetails, err = services.ScaleRecipeDetails(recipeDetails, servings); err != nil {
+			h.providerError(w, r, err, "Failed to scale recipe")
+			return
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(recipeDetails)
+}
+
+// loadRecipeDetails gets a recipe's details from local storage or the recipe provider,
+// writing the error response if it can't
+func (h *RecipeHandler) loadRecipeDetails(ctx context.Context, w http.ResponseWriter, r *http.Request, recipeID services.RecipeID) (*services.RecipeDetails, bool) {
+	// User-authored recipes are served from local storage
+	if recipeID.IsLocal() {
+		recipe, ok := h.loadMyRecipe(w, r, recipeID.Value())
+		if !ok {
+			return nil, false
+		}
+		return services.RecipeDetailsFromLocal(recipe), true
+	}
+
+	// Get recipe details from the recipe provider
+	recipeDetails, err := h.provider.GetRecipeDetailsContext(ctx, recipeID)
+	if err != nil {
+		// Log the error but don't expose internal details to client
+		fmt.Printf("Error fetching recipe details for ID %s: %v\n", recipeID, err)
+		if errors.Is(err, services.ErrUpstreamNotFound) {
+			recipeNotFound(w, r) // Spoonacular has no recipe with this ID
+			return nil, false
+		}
+		h.providerError(w, r, err, "Failed to fetch recipe details")
+		return nil, false
+	}
+	return recipeDetails, true
 } 
\ No newline at end of file
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"recipe-finder-backend/fakespoonacular"
+	"recipe-finder-backend/services"
+)
+
+// newTestRouter returns the recipe routes on a handler whose Spoonacular calls go to
+// the fake server and whose storage is a temporary directory
+func newTestRouter(t *testing.T) (*mux.Router, *fakespoonacular.Server) {
+	t.Helper()
+	fake, err := fakespoonacular.New()
+	if err != nil {
+		t.Fatalf("fakespoonacular.New: %v", err)
+	}
+	server := fake.Start()
+	t.Cleanup(server.Close)
+
+	storage, err := services.NewJSONStorageService(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewJSONStorageService() = %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	provider := services.NewSpoonacularService(storage)
+	provider.SetBaseURL(server.URL)
+	t.Cleanup(provider.Close)
+
+	handler := NewRecipeHandler(provider, storage)
+	r := mux.NewRouter()
+	r.HandleFunc("/api/v1/recipes/{id}", handler.GetRecipeDetails).Methods("GET")
+	r.HandleFunc("/api/recipes", handler.GetRecipesByIngredients).Methods("GET", "POST", "OPTIONS")
+	return r, fake
+}
+
+// serve runs a GET request through the router and decodes the JSON response into out
+func serve(t *testing.T, r http.Handler, target string, out interface{}) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if err := json.NewDecoder(rec.Body).Decode(out); err != nil {
+		t.Fatalf("GET %s: decoding the response: %v", target, err)
+	}
+	return rec.Code
+}
+
+func TestGetRecipeDetails(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		failures     int // Requests the fake fails with a 503 first
+		wantStatus   int
+		wantTitle    string
+		wantServings int
+		wantCode     string
+	}{
+		{"found", "/api/v1/recipes/660101", 0, http.StatusOK, "Chicken Fried Rice", 4, ""},
+		{"scaled", "/api/v1/recipes/660101?servings=8", 0, http.StatusOK, "Chicken Fried Rice", 8, ""},
+		{"retried", "/api/v1/recipes/660101", 1, http.StatusOK, "Chicken Fried Rice", 4, ""},
+		{"unknown recipe", "/api/v1/recipes/999999", 0, http.StatusNotFound, "", 0, CodeRecipeNotFound},
+		{"malformed ID", "/api/v1/recipes/abc", 0, http.StatusBadRequest, "", 0, CodeInvalidRecipeID},
+		{"bad servings", "/api/v1/recipes/660101?servings=lots", 0, http.StatusBadRequest, "", 0, CodeInvalidRequest},
+		{"Spoonacular down", "/api/v1/recipes/660101", 100, http.StatusServiceUnavailable, "", 0, CodeUpstreamUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, fake := newTestRouter(t)
+			if tt.failures > 0 {
+				fake.FailNext(tt.failures, http.StatusServiceUnavailable)
+			}
+
+			var body struct {
+				services.RecipeDetails
+				Error ErrorBody `json:"error"`
+			}
+			status := serve(t, r, tt.target, &body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%+v)", status, tt.wantStatus, body.Error)
+			}
+			if body.Title != tt.wantTitle || body.Servings != tt.wantServings {
+				t.Errorf("recipe = %q serving %d, want %q serving %d", body.Title, body.Servings, tt.wantTitle, tt.wantServings)
+			}
+			if body.Error.Code != tt.wantCode {
+				t.Errorf("error code = %q, want %q", body.Error.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestGetRecipesByIngredients(t *testing.T) {
+	r, fake := newTestRouter(t)
+
+	var page struct {
+		Recipes     []services.Recipe `json:"recipes"`
+		Ingredients []string          `json:"ingredients"`
+		Limit       int               `json:"limit"`
+	}
+	target := "/api/recipes?ingredients=chicken,%20rice&limit=5"
+	if status := serve(t, r, target, &page); status != http.StatusOK {
+		t.Fatalf("status = %d, want %d", status, http.StatusOK)
+	}
+	if len(page.Recipes) == 0 || len(page.Recipes) > 5 {
+		t.Errorf("%d recipes, want 1 to 5", len(page.Recipes))
+	}
+	if page.Limit != 5 {
+		t.Errorf("limit = %d, want 5", page.Limit)
+	}
+	if want := []string{"chicken", "rice"}; !reflect.DeepEqual(page.Ingredients, want) {
+		t.Errorf("ingredients = %q, want %q", page.Ingredients, want)
+	}
+	found := false
+	for _, recipe := range page.Recipes {
+		found = found || recipe.Title == "Chicken Fried Rice"
+	}
+	if !found {
+		t.Errorf("recipes = %+v, want Chicken Fried Rice among them", page.Recipes)
+	}
+
+	// The same search again is served from the cache
+	calls := fake.Calls("findByIngredients")
+	if status := serve(t, r, target, &page); status != http.StatusOK {
+		t.Fatalf("second search status = %d, want %d", status, http.StatusOK)
+	}
+	if again := fake.Calls("findByIngredients"); again != calls {
+		t.Errorf("second search called Spoonacular %d more times, want 0", again-calls)
+	}
+}
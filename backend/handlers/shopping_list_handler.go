@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"recipe-finder-backend/models"
+	"recipe-finder-backend/services"
+	"recipe-finder-backend/units"
+)
+
+// CreateShoppingList handles POST /api/v1/shopping-list. The list is JSON unless
+// ?format=text or ?format=markdown asks for a printable rendering.
+func (h *RecipeHandler) CreateShoppingList(w http.ResponseWriter, r *http.Request) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	switch format {
+	case "", "json", "text", "markdown":
+	default:
+		badRequest(w, r, "format must be json, text or markdown")
+		return
+	}
+
+	var req models.ShoppingListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest(w, r, "Invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		validationError(w, r, err)
+		return
+	}
+	system, err := units.ParseSystem(req.Units)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeValidationFailed, err.Error(),
+			map[string]interface{}{"fields": map[string]string{"units": "must be metric, us or original"}})
+		return
+	}
+
+	// Recipe IDs are all checked before any is fetched
+	recipeIDs := make([]services.RecipeID, len(req.Recipes))
+	for i, recipe := range req.Recipes {
+		if recipeIDs[i], err = services.ParseRecipeID(recipe.ID); err != nil {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidRecipeID, err.Error(), nil)
+			return
+		}
+	}
+
+	ctx, cancel := h.providerContext(r)
+	defer cancel()
+	recipes := make([]*services.RecipeDetails, 0, len(req.Recipes))
+	for i, recipe := range req.Recipes {
+		details, ok := h.loadRecipeDetails(ctx, w, r, recipeIDs[i])
+		if !ok {
+			return
+		}
+		if recipe.Servings > 0 {
+			if details, err = services.ScaleRecipeDetails(details, recipe.Servings); err != nil {
+				h.providerError(w, r, err, "Failed to scale recipe")
+				return
+			}
+		}
+		recipes = append(recipes, details)
+	}
+
+	list := services.BuildShoppingList(recipes, req.Have, system)
+	fmt.Printf("🛒 Built shopping list for %d recipes in %d aisles\n", len(recipes), len(list.Aisles))
+
+	switch format {
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(list.Text()))
+	case "markdown":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Write([]byte(list.Markdown()))
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/rs/cors"
 	"recipe-finder-backend/handlers"
+	"recipe-finder-backend/services"
 )
 
 func main() {
@@ -20,11 +21,20 @@ func main() {
 		log.Printf("Warning: .env file not found: %v", err)
 	}
 
+	// Create services
+	storageService, err := services.NewStorageService()
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	spoonacularService := services.NewSpoonacularService(storageService)
+
 	// Create handlers
-	recipeHandler := handlers.NewRecipeHandler()
+	recipeHandler := handlers.NewRecipeHandler(spoonacularService, storageService)
 
 	// Create a new router
 	r := mux.NewRouter()
+	r.NotFoundHandler = http.HandlerFunc(handlers.NotFound)
+	r.MethodNotAllowedHandler = http.HandlerFunc(handlers.MethodNotAllowed)
 
 	// API routes
 	api := r.PathPrefix("/api/v1").Subrouter()
@@ -38,9 +48,39 @@ func main() {
 	// Storage filename mapping endpoint
 	api.HandleFunc("/storage/mapping", recipeHandler.CreateFilenameMapping).Methods("POST")
 	
+	// Cache stats endpoint
+	api.HandleFunc("/cache/stats", recipeHandler.GetCacheStats).Methods("GET")
+	
+	// Spoonacular quota usage endpoint
+	api.HandleFunc("/admin/quota", recipeHandler.GetQuotaStatus).Methods("GET")
+	
 	// Ingredient search endpoint
 	api.HandleFunc("/ingredients/search", recipeHandler.SearchIngredients).Methods("GET")
 	
+	// User-authored recipe endpoints
+	api.HandleFunc("/my-recipes", recipeHandler.ListMyRecipes).Methods("GET")
+	api.HandleFunc("/my-recipes", recipeHandler.CreateMyRecipe).Methods("POST")
+	api.HandleFunc("/my-recipes/{id}", recipeHandler.GetMyRecipe).Methods("GET")
+	api.HandleFunc("/my-recipes/{id}", recipeHandler.ReplaceMyRecipe).Methods("PUT")
+	api.HandleFunc("/my-recipes/{id}", recipeHandler.UpdateMyRecipe).Methods("PATCH")
+	api.HandleFunc("/my-recipes/{id}", recipeHandler.DeleteMyRecipe).Methods("DELETE")
+	
+	// Filtered search across my recipes and cached Spoonacular recipes
+	api.HandleFunc("/search", recipeHandler.Search).Methods("GET", "POST")
+	
+	// Pantry endpoints; the pantry search MUST come before the item routes
+	api.HandleFunc("/pantry/recipes", recipeHandler.SearchPantryRecipes).Methods("GET")
+	api.HandleFunc("/pantry", recipeHandler.ListPantry).Methods("GET")
+	api.HandleFunc("/pantry", recipeHandler.CreatePantryItem).Methods("POST")
+	api.HandleFunc("/pantry/{id}", recipeHandler.GetPantryItem).Methods("GET")
+	api.HandleFunc("/pantry/{id}", recipeHandler.ReplacePantryItem).Methods("PUT")
+	api.HandleFunc("/pantry/{id}", recipeHandler.UpdatePantryItem).Methods("PATCH")
+	api.HandleFunc("/pantry/{id}", recipeHandler.DeletePantryItem).Methods("DELETE")
+	api.HandleFunc("/recipes/{id}/cooked", recipeHandler.CookRecipe).Methods("POST")
+	
+	// Combined shopping list for several recipes
+	api.HandleFunc("/shopping-list", recipeHandler.CreateShoppingList).Methods("POST")
+	
 	// Recipe search endpoint for autocomplete
 	api.HandleFunc("/search/recipes", recipeHandler.SearchRecipes).Methods("GET")
 	
@@ -69,8 +109,9 @@ func main() {
 	
 	c := cors.New(cors.Options{
 		AllowedOrigins: allowedOrigins,
-		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders: []string{"*"},
+		ExposedHeaders: []string{handlers.RequestIDHeader, "Retry-After"},
 	})
 
 	// Get port from environment or default to 8080
@@ -80,7 +121,7 @@ func main() {
 	}
 
 	// Start server
-	handler := c.Handler(r)
+	handler := handlers.RequestID(c.Handler(r))
 	fmt.Printf("🚀 Recipe Finder API server starting on port %s\n", port)
 	fmt.Printf("📚 Health check: http://localhost:%s/api/v1/health\n", port)
 	fmt.Printf("🍽️  Recipe search: POST http://localhost:%s/api/recipes\n", port)
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// DateLayout is how calendar dates, like expiry dates, are written
+const DateLayout = "2006-01-02"
+
+// PantryItem is an ingredient the user has on hand
+type PantryItem struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Amount    float64   `json:"amount"`               // 0 when the user hasn't said how much
+	Unit      string    `json:"unit"`                 // e.g. "g", "cups" or "" for a plain count
+	ExpiresOn string    `json:"expires_on,omitempty"` // YYYY-MM-DD
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreatePantryItemRequest represents the request payload for adding a pantry item
+type CreatePantryItemRequest struct {
+	Name      string  `json:"name" validate:"required"`
+	Amount    float64 `json:"amount"`
+	Unit      string  `json:"unit"`
+	ExpiresOn string  `json:"expires_on"`
+}
+
+// UpdatePantryItemRequest represents the request payload for updating a pantry item
+type UpdatePantryItemRequest struct {
+	Name      *string  `json:"name,omitempty"`
+	Amount    *float64 `json:"amount,omitempty"`
+	Unit      *string  `json:"unit,omitempty"`
+	ExpiresOn *string  `json:"expires_on,omitempty"` // "" clears the expiry date
+}
+
+// Expiry returns the day the item expires, and false if it has no expiry date
+func (p *PantryItem) Expiry() (time.Time, bool) {
+	if p.ExpiresOn == "" {
+		return time.Time{}, false
+	}
+	day, err := time.Parse(DateLayout, p.ExpiresOn)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return day, true
+}
+
+// Validate checks the required fields and value ranges of a create request
+func (r *CreatePantryItemRequest) Validate() error {
+	v := &ValidationError{}
+	if strings.TrimSpace(r.Name) == "" {
+		v.add("name", "is required")
+	}
+	validatePantryAmount(v, r.Amount)
+	validateDate(v, "expires_on", r.ExpiresOn)
+	return v.errorOrNil()
+}
+
+// NewPantryItem builds a pantry item with the given ID from a create request
+func (r *CreatePantryItemRequest) NewPantryItem(id string, now time.Time) *PantryItem {
+	return &PantryItem{
+		ID:        id,
+		Name:      strings.TrimSpace(r.Name),
+		Amount:    r.Amount,
+		Unit:      strings.TrimSpace(r.Unit),
+		ExpiresOn: strings.TrimSpace(r.ExpiresOn),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Validate checks the fields that are present in an update request
+func (r *UpdatePantryItemRequest) Validate() error {
+	v := &ValidationError{}
+	if r.Name != nil && strings.TrimSpace(*r.Name) == "" {
+		v.add("name", "must not be empty")
+	}
+	if r.Amount != nil {
+		validatePantryAmount(v, *r.Amount)
+	}
+	if r.ExpiresOn != nil {
+		validateDate(v, "expires_on", *r.ExpiresOn)
+	}
+	return v.errorOrNil()
+}
+
+// Apply copies every field that is set in the update request onto the pantry item
+func (r *UpdatePantryItemRequest) Apply(item *PantryItem, now time.Time) {
+	if r.Name != nil {
+		item.Name = strings.TrimSpace(*r.Name)
+	}
+	if r.Amount != nil {
+		item.Amount = *r.Amount
+	}
+	if r.Unit != nil {
+		item.Unit = strings.TrimSpace(*r.Unit)
+	}
+	if r.ExpiresOn != nil {
+		item.ExpiresOn = strings.TrimSpace(*r.ExpiresOn)
+	}
+	item.UpdatedAt = now
+}
+
+// validatePantryAmount rejects negative amounts
+func validatePantryAmount(v *ValidationError, amount float64) {
+	if amount < 0 {
+		v.add("amount", "must not be negative")
+	}
+}
+
+// validateDate rejects dates that aren't written YYYY-MM-DD; an empty date is allowed
+func validateDate(v *ValidationError, field, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	if _, err := time.Parse(DateLayout, value); err != nil {
+		v.add(field, "must be a date written YYYY-MM-DD")
+	}
+}
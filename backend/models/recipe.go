@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
 
 // Recipe represents a recipe in the system
 type Recipe struct {
@@ -58,4 +63,249 @@ type SearchRecipeRequest struct {
 	Tags       []string `json:"tags"`
 	MaxPrepTime int     `json:"max_prep_time"`
 	MaxCookTime int     `json:"max_cook_time"`
-} 
\ No newline at end of file
+	Limit       int     `json:"limit"`
+	Offset      int     `json:"offset"`
+} 
+
+// MaxShoppingListRecipes bounds the recipes one shopping list combines
+const MaxShoppingListRecipes = 20
+
+// ShoppingListRequest represents the request payload for building a shopping list
+type ShoppingListRequest struct {
+	Recipes []ShoppingListRecipe `json:"recipes"`
+	Have    []string             `json:"have"`  // Ingredient lines the user already has, e.g. "1 cup flour" or "salt"
+	Units   string               `json:"units"` // metric, us or original
+}
+
+// ShoppingListRecipe is a recipe to shop for and the servings to make
+type ShoppingListRecipe struct {
+	ID       string `json:"id"`
+	Servings int    `json:"servings"` // The recipe's own servings if 0
+}
+
+// Difficulty levels accepted for a recipe
+var Difficulties = []string{"easy", "medium", "hard"}
+
+// ValidationError reports the request fields that failed validation
+type ValidationError struct {
+	Fields map[string]string `json:"fields"`
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	problems := make([]string, 0, len(names))
+	for _, name := range names {
+		problems = append(problems, fmt.Sprintf("%s %s", name, e.Fields[name]))
+	}
+	return "invalid request: " + strings.Join(problems, "; ")
+}
+
+// add records a problem with a field
+func (e *ValidationError) add(field, problem string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string]string)
+	}
+	e.Fields[field] = problem
+}
+
+// errorOrNil returns the validation error if any field failed
+func (e *ValidationError) errorOrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Validate checks the required fields and value ranges of a create request
+func (r *CreateRecipeRequest) Validate() error {
+	v := &ValidationError{}
+	if strings.TrimSpace(r.Title) == "" {
+		v.add("title", "is required")
+	}
+	if !hasNonBlank(r.Ingredients) {
+		v.add("ingredients", "is required")
+	}
+	if !hasNonBlank(r.Instructions) {
+		v.add("instructions", "is required")
+	}
+	validateCounts(v, r.PrepTime, r.CookTime, r.Servings)
+	validateDifficulty(v, r.Difficulty)
+	return v.errorOrNil()
+}
+
+// Validate checks the filter values of a search request
+func (r *SearchRecipeRequest) Validate() error {
+	v := &ValidationError{}
+	validateDifficulty(v, r.Difficulty)
+	if r.MaxPrepTime < 0 {
+		v.add("max_prep_time", "must not be negative")
+	}
+	if r.MaxCookTime < 0 {
+		v.add("max_cook_time", "must not be negative")
+	}
+	if r.Limit < 0 {
+		v.add("limit", "must not be negative")
+	}
+	if r.Offset < 0 {
+		v.add("offset", "must not be negative")
+	}
+	return v.errorOrNil()
+}
+
+// Validate checks the recipes of a shopping list request
+func (r *ShoppingListRequest) Validate() error {
+	v := &ValidationError{}
+	if len(r.Recipes) == 0 {
+		v.add("recipes", "is required")
+	}
+	if len(r.Recipes) > MaxShoppingListRecipes {
+		v.add("recipes", fmt.Sprintf("must not list more than %d recipes", MaxShoppingListRecipes))
+	}
+	for i, recipe := range r.Recipes {
+		if strings.TrimSpace(recipe.ID) == "" {
+			v.add(fmt.Sprintf("recipes[%d].id", i), "is required")
+		}
+		if recipe.Servings < 0 {
+			v.add(fmt.Sprintf("recipes[%d].servings", i), "must not be negative")
+		}
+	}
+	return v.errorOrNil()
+}
+
+// NewRecipe builds a recipe with the given ID from a create request
+func (r *CreateRecipeRequest) NewRecipe(id string, now time.Time) *Recipe {
+	return &Recipe{
+		ID:           id,
+		Title:        strings.TrimSpace(r.Title),
+		Description:  r.Description,
+		Ingredients:  compact(r.Ingredients),
+		Instructions: compact(r.Instructions),
+		PrepTime:     r.PrepTime,
+		CookTime:     r.CookTime,
+		Servings:     r.Servings,
+		Difficulty:   strings.ToLower(r.Difficulty),
+		Category:     r.Category,
+		Tags:         compact(r.Tags),
+		ImageURL:     r.ImageURL,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+// Validate checks the fields that are present in an update request
+func (r *UpdateRecipeRequest) Validate() error {
+	v := &ValidationError{}
+	if r.Title != nil && strings.TrimSpace(*r.Title) == "" {
+		v.add("title", "must not be empty")
+	}
+	if r.Ingredients != nil && !hasNonBlank(*r.Ingredients) {
+		v.add("ingredients", "must not be empty")
+	}
+	if r.Instructions != nil && !hasNonBlank(*r.Instructions) {
+		v.add("instructions", "must not be empty")
+	}
+	validateCounts(v, derefInt(r.PrepTime), derefInt(r.CookTime), derefInt(r.Servings))
+	if r.Difficulty != nil {
+		validateDifficulty(v, *r.Difficulty)
+	}
+	return v.errorOrNil()
+}
+
+// Apply copies every field that is set in the update request onto the recipe
+func (r *UpdateRecipeRequest) Apply(recipe *Recipe, now time.Time) {
+	if r.Title != nil {
+		recipe.Title = strings.TrimSpace(*r.Title)
+	}
+	if r.Description != nil {
+		recipe.Description = *r.Description
+	}
+	if r.Ingredients != nil {
+		recipe.Ingredients = compact(*r.Ingredients)
+	}
+	if r.Instructions != nil {
+		recipe.Instructions = compact(*r.Instructions)
+	}
+	if r.PrepTime != nil {
+		recipe.PrepTime = *r.PrepTime
+	}
+	if r.CookTime != nil {
+		recipe.CookTime = *r.CookTime
+	}
+	if r.Servings != nil {
+		recipe.Servings = *r.Servings
+	}
+	if r.Difficulty != nil {
+		recipe.Difficulty = strings.ToLower(*r.Difficulty)
+	}
+	if r.Category != nil {
+		recipe.Category = *r.Category
+	}
+	if r.Tags != nil {
+		recipe.Tags = compact(*r.Tags)
+	}
+	if r.ImageURL != nil {
+		recipe.ImageURL = *r.ImageURL
+	}
+	recipe.UpdatedAt = now
+}
+
+// validateCounts rejects negative times and servings
+func validateCounts(v *ValidationError, prepTime, cookTime, servings int) {
+	if prepTime < 0 {
+		v.add("prep_time", "must not be negative")
+	}
+	if cookTime < 0 {
+		v.add("cook_time", "must not be negative")
+	}
+	if servings < 0 {
+		v.add("servings", "must not be negative")
+	}
+}
+
+// validateDifficulty rejects difficulty levels other than easy, medium and hard
+func validateDifficulty(v *ValidationError, difficulty string) {
+	if difficulty == "" {
+		return
+	}
+	for _, d := range Difficulties {
+		if strings.EqualFold(difficulty, d) {
+			return
+		}
+	}
+	v.add("difficulty", "must be one of "+strings.Join(Difficulties, ", "))
+}
+
+// hasNonBlank reports whether any entry has non-whitespace content
+func hasNonBlank(values []string) bool {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// compact trims every entry and drops the blank ones
+func compact(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
+
+// derefInt returns the pointed-to value or zero
+func derefInt(value *int) int {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"container/list"
+	"encoding/json"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultCacheMaxEntries bounds the number of entries when CACHE_MAX_ENTRIES is not set
+	DefaultCacheMaxEntries = 1000
+	// DefaultCacheMaxBytes bounds the estimated cache size when CACHE_MAX_BYTES is not set
+	DefaultCacheMaxBytes = 64 << 20
+	// DefaultCacheSweepInterval is how often expired entries are removed in the background
+	DefaultCacheSweepInterval = time.Minute
+)
+
+// Cache stores API responses in memory. LRUCache is the default implementation;
+// a shared cache can be plugged in with SpoonacularService.SetCache.
+type Cache interface {
+	// Get returns a live entry and whether it was found
+	Get(key string) (interface{}, bool)
+	// Set stores an entry that expires after ttl
+	Set(key string, value interface{}, ttl time.Duration)
+	// Delete removes an entry if present
+	Delete(key string)
+	// Stats returns a snapshot of the cache counters
+	Stats() CacheStats
+	// Close stops any background work
+	Close()
+}
+
+// CacheReporter is implemented by recipe providers that can report on their cache
+type CacheReporter interface {
+	CacheStats() CacheStats
+}
+
+// CacheStats is a snapshot of cache size and counters
+type CacheStats struct {
+	Entries     int     `json:"entries"`
+	Bytes       int64   `json:"bytes"`
+	MaxEntries  int     `json:"maxEntries"`
+	MaxBytes    int64   `json:"maxBytes"`
+	Hits        uint64  `json:"hits"`
+	Misses      uint64  `json:"misses"`
+	Evictions   uint64  `json:"evictions"`
+	Expirations uint64  `json:"expirations"`
+	HitRate     float64 `json:"hitRate"`
+}
+
+// getCacheMaxEntries returns the cache entry limit from environment variables
+func getCacheMaxEntries() int {
+	if entries := os.Getenv("CACHE_MAX_ENTRIES"); entries != "" {
+		if n, err := strconv.Atoi(entries); err == nil && n > 0 {
+			return n
+		}
+	}
+	return DefaultCacheMaxEntries
+}
+
+// getCacheMaxBytes returns the cache size limit from environment variables
+func getCacheMaxBytes() int64 {
+	if bytes := os.Getenv("CACHE_MAX_BYTES"); bytes != "" {
+		if n, err := strconv.ParseInt(bytes, 10, 64); err == nil && n > 0 {
+			return n
+		}
+	}
+	return DefaultCacheMaxBytes
+}
+
+// lruEntry is a cached value together with its expiry and estimated size
+type lruEntry struct {
+	key       string
+	value     interface{}
+	size      int64
+	expiresAt time.Time
+}
+
+// LRUCache is an in-memory cache bounded by entry count and estimated size in bytes.
+// When either bound is exceeded the least recently used entries are evicted, and a
+// background sweeper removes expired entries so they don't hold memory until evicted.
+type LRUCache struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List // front is most recently used
+	bytes      int64
+	maxEntries int
+	maxBytes   int64
+
+	hits        uint64
+	misses      uint64
+	evictions   uint64
+	expirations uint64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Ensure LRUCache satisfies Cache
+var _ Cache = (*LRUCache)(nil)
+
+// NewLRUCache creates a cache holding at most maxEntries entries and maxBytes estimated
+// bytes, sweeping expired entries every sweepInterval (no sweeper if sweepInterval <= 0)
+func NewLRUCache(maxEntries int, maxBytes int64, sweepInterval time.Duration) *LRUCache {
+	c := &LRUCache{
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		stop:       make(chan struct{}),
+	}
+	if sweepInterval > 0 {
+		go c.sweep(sweepInterval)
+	}
+	return c
+}
+
+// NewLRUCacheFromEnv creates a cache bounded by CACHE_MAX_ENTRIES and CACHE_MAX_BYTES
+func NewLRUCacheFromEnv() *LRUCache {
+	return NewLRUCache(getCacheMaxEntries(), getCacheMaxBytes(), DefaultCacheSweepInterval)
+}
+
+// Get returns a live entry and marks it as recently used
+func (c *LRUCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, exists := c.entries[key]
+	if !exists {
+		c.misses++
+		return nil, false
+	}
+
+	entry := element.Value.(*lruEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.removeElement(element)
+		c.expirations++
+		c.misses++
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	c.hits++
+	return entry.value, true
+}
+
+// Set stores an entry, evicting least recently used entries to stay within bounds.
+// Values larger than the byte bound are not cached at all.
+func (c *LRUCache) Set(key string, value interface{}, ttl time.Duration) {
+	size := estimateSize(key, value)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, exists := c.entries[key]; exists {
+		c.removeElement(element)
+	}
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return
+	}
+
+	entry := &lruEntry{
+		key:       key,
+		value:     value,
+		size:      size,
+		expiresAt: time.Now().Add(ttl),
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	c.bytes += size
+
+	for c.overLimit() {
+		oldest := c.order.Back()
+		if oldest == nil {
+			break
+		}
+		c.removeElement(oldest)
+		c.evictions++
+	}
+}
+
+// Delete removes an entry if present
+func (c *LRUCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, exists := c.entries[key]; exists {
+		c.removeElement(element)
+	}
+}
+
+// Stats returns a snapshot of the cache counters
+func (c *LRUCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := CacheStats{
+		Entries:     len(c.entries),
+		Bytes:       c.bytes,
+		MaxEntries:  c.maxEntries,
+		MaxBytes:    c.maxBytes,
+		Hits:        c.hits,
+		Misses:      c.misses,
+		Evictions:   c.evictions,
+		Expirations: c.expirations,
+	}
+	if lookups := c.hits + c.misses; lookups > 0 {
+		stats.HitRate = float64(c.hits) / float64(lookups)
+	}
+	return stats
+}
+
+// Close stops the background sweeper
+func (c *LRUCache) Close() {
+	c.stopOnce.Do(func() { close(c.stop) })
+}
+
+// sweep removes expired entries every interval until the cache is closed
+func (c *LRUCache) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.removeExpired()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// removeExpired drops every entry whose expiry has passed
+func (c *LRUCache) removeExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for element := c.order.Back(); element != nil; {
+		previous := element.Prev()
+		if now.After(element.Value.(*lruEntry).expiresAt) {
+			c.removeElement(element)
+			c.expirations++
+		}
+		element = previous
+	}
+}
+
+// overLimit reports whether the cache holds more than its bounds allow; callers must hold the lock
+func (c *LRUCache) overLimit() bool {
+	return (c.maxEntries > 0 && len(c.entries) > c.maxEntries) ||
+		(c.maxBytes > 0 && c.bytes > c.maxBytes)
+}
+
+// removeElement unlinks an entry; callers must hold the lock
+func (c *LRUCache) removeElement(element *list.Element) {
+	entry := element.Value.(*lruEntry)
+	c.order.Remove(element)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+}
+
+// estimateSize approximates the memory held by an entry from its JSON encoding
+func estimateSize(key string, value interface{}) int64 {
+	size := int64(len(key))
+	if data, err := json.Marshal(value); err == nil {
+		size += int64(len(data))
+	} else {
+		size += 1024 // Unknown shape, assume a small object
+	}
+	return size
+}
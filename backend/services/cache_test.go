@@ -0,0 +1,96 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cachedKeys returns which of keys the cache holds, without touching their recency
+func cachedKeys(c *LRUCache, keys ...string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	held := []string{}
+	for _, key := range keys {
+		if _, ok := c.entries[key]; ok {
+			held = append(held, key)
+		}
+	}
+	return held
+}
+
+func TestLRUCacheEntryBound(t *testing.T) {
+	c := NewLRUCache(3, 0, 0)
+	defer c.Close()
+
+	for _, key := range []string{"a", "b", "c"} {
+		c.Set(key, key, time.Hour)
+	}
+	c.Get("a") // Now b is the least recently used
+	c.Set("d", "d", time.Hour)
+	c.Set("e", "e", time.Hour)
+
+	if got, want := cachedKeys(c, "a", "b", "c", "d", "e"), []string{"a", "d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cache holds %q, want %q", got, want)
+	}
+	if stats := c.Stats(); stats.Entries != 3 || stats.Evictions != 2 {
+		t.Errorf("stats = %+v, want 3 entries and 2 evictions", stats)
+	}
+}
+
+func TestLRUCacheByteBound(t *testing.T) {
+	// Each entry is its one-byte key plus a quoted 8-byte string: 11 bytes
+	c := NewLRUCache(0, 30, 0)
+	defer c.Close()
+
+	c.Set("a", "aaaaaaaa", time.Hour)
+	c.Set("b", "bbbbbbbb", time.Hour)
+	if stats := c.Stats(); stats.Bytes != 22 {
+		t.Errorf("bytes = %d, want 22", stats.Bytes)
+	}
+	c.Set("c", "cccccccc", time.Hour)
+	if got, want := cachedKeys(c, "a", "b", "c"), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cache holds %q, want %q", got, want)
+	}
+
+	// Replacing an entry accounts for its new size only
+	c.Set("c", "cc", time.Hour)
+	if stats := c.Stats(); stats.Bytes != 16 {
+		t.Errorf("bytes after replacing = %d, want 16", stats.Bytes)
+	}
+
+	// A value larger than the whole cache isn't kept, and evicts nothing
+	c.Set("big", strings.Repeat("x", 100), time.Hour)
+	if got, want := cachedKeys(c, "b", "c", "big"), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cache holds %q after an oversized value, want %q", got, want)
+	}
+}
+
+func TestLRUCacheExpiry(t *testing.T) {
+	c := NewLRUCache(10, 0, 0)
+	defer c.Close()
+
+	c.Set("live", 1, time.Hour)
+	c.Set("expired", 2, -time.Second)
+	c.Set("swept", 3, -time.Second)
+
+	if _, ok := c.Get("expired"); ok {
+		t.Errorf("Get() returned an expired entry")
+	}
+	if value, ok := c.Get("live"); !ok || value != 1 {
+		t.Errorf("Get(live) = %v, %v, want 1, true", value, ok)
+	}
+	c.removeExpired()
+	if got, want := cachedKeys(c, "live", "expired", "swept"), []string{"live"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cache holds %q after sweeping, want %q", got, want)
+	}
+
+	c.Delete("live")
+	c.Get("live")
+	stats := c.Stats()
+	want := CacheStats{MaxEntries: 10, Hits: 1, Misses: 2, Expirations: 2, HitRate: 1.0 / 3}
+	if stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+}
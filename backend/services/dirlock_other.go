@@ -0,0 +1,9 @@
+//go:build !unix
+
+package services
+
+// lockFile is a no-op on platforms without flock; writes are still atomic and
+// serialized within the process, but not across processes.
+func lockFile(path string, exclusive bool) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,46 @@
+//go:build unix
+
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), lockFilename)
+
+	// Shared locks don't wait for each other
+	unlockA, err := lockFile(path, false)
+	if err != nil {
+		t.Fatalf("lockFile(shared) = %v", err)
+	}
+	unlockB, err := lockFile(path, false)
+	if err != nil {
+		t.Fatalf("second lockFile(shared) = %v", err)
+	}
+
+	// An exclusive lock waits for both to be released
+	acquired := make(chan func())
+	go func() {
+		unlock, err := lockFile(path, true)
+		if err != nil {
+			t.Errorf("lockFile(exclusive) = %v", err)
+		}
+		acquired <- unlock
+	}()
+	unlockA()
+	select {
+	case <-acquired:
+		t.Fatalf("exclusive lock granted while a shared lock was held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlockB()
+	select {
+	case unlock := <-acquired:
+		unlock()
+	case <-time.After(5 * time.Second):
+		t.Fatalf("exclusive lock not granted after the shared locks were released")
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// flightCall is an upstream request in progress, shared by every caller asking for the same key
+type flightCall struct {
+	done    chan struct{}
+	value   interface{}
+	err     error
+	dups    int                // Callers that joined after the first
+	waiters int                // Callers still waiting for the result
+	cancel  context.CancelFunc // Cancels the request once every waiter has gone
+}
+
+// flightGroup coalesces concurrent calls for the same key so that only the first one
+// runs and the others wait for and share its result. The call runs with a context
+// detached from any single caller; it is cancelled only when every caller waiting for
+// it has given up, so one disconnecting client doesn't fail the others. The zero value
+// is ready to use.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// Do runs fn once for all concurrent callers with the same key. If ctx ends first, Do
+// returns its error without waiting. shared reports whether the result was also handed
+// to other callers, who must then treat it as read-only.
+func (g *flightGroup) Do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (value interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if call, ok := g.calls[key]; ok {
+		call.dups++
+		call.waiters++
+		g.mu.Unlock()
+		return g.wait(ctx, key, call)
+	}
+
+	// Keep the first caller's values (but not its cancellation) for the shared request
+	flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	call := &flightCall{done: make(chan struct{}), waiters: 1, cancel: cancel}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	go g.run(flightCtx, key, call, fn)
+	return g.wait(ctx, key, call)
+}
+
+// run executes a flight's function and publishes its result
+func (g *flightGroup) run(ctx context.Context, key string, call *flightCall, fn func(ctx context.Context) (interface{}, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			call.value, call.err = nil, fmt.Errorf("request for %s panicked: %v", key, r)
+		}
+		call.cancel()
+
+		g.mu.Lock()
+		if g.calls[key] == call {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.value, call.err = fn(ctx)
+}
+
+// wait blocks until the flight finishes or ctx ends; the last waiter to give up cancels the flight
+func (g *flightGroup) wait(ctx context.Context, key string, call *flightCall) (interface{}, error, bool) {
+	select {
+	case <-call.done:
+		g.mu.Lock()
+		shared := call.dups > 0
+		g.mu.Unlock()
+		return call.value, call.err, shared
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			call.cancel()
+			// Let the next caller start afresh instead of joining a cancelled request
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err(), false
+	}
+}
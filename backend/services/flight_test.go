@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFlightGroupSharesConcurrentCalls(t *testing.T) {
+	var g flightGroup
+	var runs int32
+	release := make(chan struct{})
+	fn := func(context.Context) (interface{}, error) {
+		atomic.AddInt32(&runs, 1)
+		<-release
+		return "result", nil
+	}
+
+	const callers = 5
+	type outcome struct {
+		value  interface{}
+		err    error
+		shared bool
+	}
+	outcomes := make(chan outcome, callers)
+	var started sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		started.Add(1)
+		go func() {
+			started.Done()
+			value, err, shared := g.Do(context.Background(), "key", fn)
+			outcomes <- outcome{value, err, shared}
+		}()
+	}
+	started.Wait()
+
+	// Let every caller join the flight before it finishes
+	deadline := time.Now().Add(time.Second)
+	for {
+		g.mu.Lock()
+		call := g.calls["key"]
+		joined := call != nil && call.dups == callers-1
+		g.mu.Unlock()
+		if joined {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("callers did not join the flight")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	for i := 0; i < callers; i++ {
+		got := <-outcomes
+		if got.value != "result" || got.err != nil || !got.shared {
+			t.Errorf("Do() = %v, %v, shared %v; want result, nil, shared true", got.value, got.err, got.shared)
+		}
+	}
+	if runs != 1 {
+		t.Errorf("fn ran %d times, want 1", runs)
+	}
+}
+
+func TestFlightGroupSequentialCalls(t *testing.T) {
+	var g flightGroup
+	runs := 0
+	fn := func(context.Context) (interface{}, error) {
+		runs++
+		return runs, nil
+	}
+
+	// A finished flight isn't reused; the next call runs fn again
+	for want := 1; want <= 2; want++ {
+		value, err, shared := g.Do(context.Background(), "key", fn)
+		if value != want || err != nil || shared {
+			t.Errorf("Do() = %v, %v, shared %v; want %d, nil, shared false", value, err, shared, want)
+		}
+	}
+	if len(g.calls) != 0 {
+		t.Errorf("%d flights left behind, want 0", len(g.calls))
+	}
+}
+
+func TestFlightGroupErrors(t *testing.T) {
+	var g flightGroup
+	errFailed := errors.New("upstream failed")
+	fail := func(context.Context) (interface{}, error) { return nil, errFailed }
+	if _, err, _ := g.Do(context.Background(), "key", fail); err != errFailed {
+		t.Errorf("Do() error = %v, want %v", err, errFailed)
+	}
+
+	// A failure isn't remembered for the next call
+	succeed := func(context.Context) (interface{}, error) { return "ok", nil }
+	value, err, _ := g.Do(context.Background(), "key", succeed)
+	if value != "ok" || err != nil {
+		t.Errorf("Do() after a failure = %v, %v; want ok, nil", value, err)
+	}
+}
+
+func TestFlightGroupWaiterGivesUp(t *testing.T) {
+	var g flightGroup
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (interface{}, error) {
+		select {
+		case <-release:
+			return "result", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	first := make(chan error, 1)
+	go func() {
+		_, err, _ := g.Do(context.Background(), "key", fn)
+		first <- err
+	}()
+
+	// A second caller that gives up doesn't wait, and doesn't cancel the first one's call
+	for {
+		g.mu.Lock()
+		started := g.calls["key"] != nil
+		g.mu.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err, _ := g.Do(ctx, "key", fn); !errors.Is(err, context.Canceled) {
+		t.Errorf("Do() with a cancelled context = %v, want context.Canceled", err)
+	}
+
+	close(release)
+	if err := <-first; err != nil {
+		t.Errorf("Do() of the remaining caller = %v, want nil", err)
+	}
+}
+
+func TestFlightGroupAllWaitersGiveUp(t *testing.T) {
+	var g flightGroup
+	cancelled := make(chan error, 1)
+	fn := func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return nil, ctx.Err()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err, _ := g.Do(ctx, "key", fn); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do() = %v, want context.DeadlineExceeded", err)
+	}
+
+	// With nobody left waiting the call itself is cancelled
+	select {
+	case err := <-cancelled:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("the call's context ended with %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the call wasn't cancelled after its only caller gave up")
+	}
+}
+
+func TestFlightGroupPanic(t *testing.T) {
+	var g flightGroup
+	_, err, _ := g.Do(context.Background(), "key", func(context.Context) (interface{}, error) {
+		panic("boom")
+	})
+	if err == nil {
+		t.Error("Do() of a panicking call = nil error, want one")
+	}
+}
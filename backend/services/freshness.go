@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultSoftTTL is how long stored Spoonacular data is served as fresh when
+	// STORAGE_SOFT_TTL_HOURS is not set
+	DefaultSoftTTL = 7 * 24 * time.Hour
+	// DefaultHardTTL is how long stored Spoonacular data may be served while it is
+	// refreshed in the background when STORAGE_HARD_TTL_HOURS is not set
+	DefaultHardTTL = 30 * 24 * time.Hour
+)
+
+// Freshness classifies stored data by age
+type Freshness int
+
+const (
+	// Fresh data is younger than the soft TTL and served as is
+	Fresh Freshness = iota
+	// Stale data is past the soft TTL but within the hard TTL: it is served
+	// immediately while a background refresh replaces it
+	Stale
+	// Expired data is past the hard TTL: it is only served when the upstream call fails
+	Expired
+)
+
+// String names a freshness class for logs
+func (f Freshness) String() string {
+	switch f {
+	case Fresh:
+		return "fresh"
+	case Stale:
+		return "stale"
+	default:
+		return "expired"
+	}
+}
+
+// getStorageSoftTTL returns the soft TTL from environment variables
+func getStorageSoftTTL() time.Duration {
+	if hours := os.Getenv("STORAGE_SOFT_TTL_HOURS"); hours != "" {
+		if h, err := strconv.Atoi(hours); err == nil && h >= 0 {
+			return time.Duration(h) * time.Hour
+		}
+	}
+	return DefaultSoftTTL
+}
+
+// getStorageHardTTL returns the hard TTL from environment variables; it is never
+// shorter than the soft TTL
+func getStorageHardTTL() time.Duration {
+	hardTTL := DefaultHardTTL
+	if hours := os.Getenv("STORAGE_HARD_TTL_HOURS"); hours != "" {
+		if h, err := strconv.Atoi(hours); err == nil && h >= 0 {
+			hardTTL = time.Duration(h) * time.Hour
+		}
+	}
+	if softTTL := getStorageSoftTTL(); hardTTL < softTTL {
+		return softTTL
+	}
+	return hardTTL
+}
+
+// FreshnessOf classifies data stored at storedAt against the configured TTLs
+func FreshnessOf(storedAt time.Time) Freshness {
+	age := time.Since(storedAt)
+	switch {
+	case age <= getStorageSoftTTL():
+		return Fresh
+	case age <= getStorageHardTTL():
+		return Stale
+	default:
+		return Expired
+	}
+}
+
+// cachedLookup describes how to serve one Spoonacular resource from the memory
+// cache, persistent storage or the API
+type cachedLookup struct {
+	// description names the resource in logs, e.g. "recipe details: 716429"
+	description string
+	// cacheKey is the memory cache and request coalescing key
+	cacheKey string
+	// load reads the stored copy and when it was stored; a nil value means nothing is stored
+	load func() (interface{}, time.Time, error)
+	// fetch calls the API, stores the result and caches it in memory
+	fetch func(ctx context.Context) (interface{}, error)
+	// markStale returns a copy of a stored value flagged as stale
+	markStale func(value interface{}) interface{}
+}
+
+// lookup serves a resource from the memory cache, then from storage, then from the API.
+// Stale stored data is returned at once while a background refresh runs; if the API
+// call fails, any stored copy is returned flagged as stale instead of the error.
+// shared reports whether the value came from an API call shared with other callers.
+// If ctx ends before the API answers, lookup returns at once; the call itself is only
+// cancelled once no other caller is waiting for it.
+func (s *SpoonacularService) lookup(ctx context.Context, l cachedLookup) (value interface{}, shared bool, err error) {
+	// Check memory cache first (fastest)
+	if cachedData := s.getFromCache(l.cacheKey); cachedData != nil {
+		fmt.Printf("⚡ Using memory cache for %s\n", l.description)
+		return cachedData, false, nil
+	}
+
+	// Check persistent storage (still faster than API call)
+	stored, storedAt, err := l.load()
+	if err != nil {
+		stored = nil
+	}
+	if stored != nil {
+		switch freshness := FreshnessOf(storedAt); freshness {
+		case Fresh:
+			// Also cache in memory for faster subsequent access
+			s.setCache(l.cacheKey, stored)
+			return stored, false, nil
+		case Stale:
+			fmt.Printf("📅 Serving stale %s while refreshing in the background\n", l.description)
+			go s.refresh(l)
+			return l.markStale(stored), false, nil
+		default:
+			fmt.Printf("📅 Stored %s is expired, will refresh\n", l.description)
+		}
+	}
+
+	// Concurrent requests for the same resource share one API call
+	result, err, shared := s.flights.Do(ctx, l.cacheKey, func(ctx context.Context) (interface{}, error) {
+		if cachedData := s.getFromCache(l.cacheKey); cachedData != nil {
+			return cachedData, nil
+		}
+		return l.fetch(ctx)
+	})
+	if err != nil {
+		// Nobody is left to read a fallback once the client has gone away
+		if stored != nil && !errors.Is(err, context.Canceled) {
+			fmt.Printf("🛟 API call for %s failed, serving stored copy: %v\n", l.description, err)
+			return l.markStale(stored), false, nil
+		}
+		return nil, false, err
+	}
+	if shared {
+		fmt.Printf("🔗 Shared in-flight API call for %s\n", l.description)
+	}
+	return result, shared, nil
+}
+
+// refresh replaces stale stored data with a fresh API response; concurrent refreshes
+// of the same resource share one call. It outlives the request that triggered it, so
+// it runs under its own deadline.
+func (s *SpoonacularService) refresh(l cachedLookup) {
+	ctx, cancel := context.WithTimeout(context.Background(), GetRequestTimeout())
+	defer cancel()
+
+	_, err, _ := s.flights.Do(ctx, l.cacheKey, l.fetch)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Background refresh of %s failed: %v\n", l.description, err)
+	}
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"recipe-finder-backend/units"
+)
+
+// BM25 tuning parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Field weights applied to term frequencies, so a title hit counts more than an instruction hit
+const (
+	titleWeight       = 3.0
+	ingredientWeight  = 2.0
+	descriptionWeight = 1.0
+	instructionWeight = 1.0
+)
+
+// indexStopWords are ignored when indexing and querying
+var indexStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "for": true, "from": true, "in": true, "into": true, "is": true, "it": true,
+	"of": true, "on": true, "or": true, "the": true, "this": true, "to": true, "with": true,
+}
+
+// SearchIndex is an in-memory inverted index over stored recipes ranked with BM25
+type SearchIndex struct {
+	mu          sync.RWMutex
+	documents   map[string]*indexedDocument
+	postings    map[string]map[string]float64 // term -> document key -> weighted term frequency
+	totalLength float64
+}
+
+// indexedDocument is a recipe in the index together with its term statistics
+type indexedDocument struct {
+	result   SearchResult
+	detailed bool
+	terms    map[string]float64
+	length   float64
+}
+
+// NewSearchIndex creates an empty search index
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		documents: make(map[string]*indexedDocument),
+		postings:  make(map[string]map[string]float64),
+	}
+}
+
+// Add indexes a recipe, replacing any earlier version with the same source and ID.
+// Detailed documents (full recipe details or local recipes) are never replaced by
+// search summaries of the same recipe.
+func (idx *SearchIndex) Add(result SearchResult, detailed bool) {
+	key := indexKey(result.Source, result.ID)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if existing, ok := idx.documents[key]; ok {
+		if existing.detailed && !detailed {
+			return
+		}
+		idx.removeLocked(key)
+	}
+
+	terms := make(map[string]float64)
+	addTerms(terms, result.Title, titleWeight)
+	addTerms(terms, strings.Join(result.Ingredients, " "), ingredientWeight)
+	addTerms(terms, result.Description, descriptionWeight)
+	addTerms(terms, strings.Join(result.instructions, " "), instructionWeight)
+
+	doc := &indexedDocument{
+		result:   result,
+		detailed: detailed,
+		terms:    terms,
+	}
+	for term, frequency := range terms {
+		doc.length += frequency
+		postings, ok := idx.postings[term]
+		if !ok {
+			postings = make(map[string]float64)
+			idx.postings[term] = postings
+		}
+		postings[key] = frequency
+	}
+
+	idx.documents[key] = doc
+	idx.totalLength += doc.length
+}
+
+// Remove drops a recipe from the index
+func (idx *SearchIndex) Remove(source, id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(indexKey(source, id))
+}
+
+// Reset empties the index
+func (idx *SearchIndex) Reset() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.documents = make(map[string]*indexedDocument)
+	idx.postings = make(map[string]map[string]float64)
+	idx.totalLength = 0
+}
+
+// Len returns the number of indexed recipes
+func (idx *SearchIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.documents)
+}
+
+// Documents returns a copy of every indexed recipe
+func (idx *SearchIndex) Documents() []SearchResult {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	results := make([]SearchResult, 0, len(idx.documents))
+	for _, doc := range idx.documents {
+		results = append(results, doc.result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return indexKey(results[i].Source, results[i].ID) < indexKey(results[j].Source, results[j].ID)
+	})
+	return results
+}
+
+// Score returns the BM25 score of every recipe matching at least one query term,
+// keyed by indexKey(source, id)
+func (idx *SearchIndex) Score(query string) map[string]float64 {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	scores := make(map[string]float64)
+	if len(idx.documents) == 0 {
+		return scores
+	}
+
+	n := float64(len(idx.documents))
+	avgLength := idx.totalLength / n
+	if avgLength == 0 {
+		avgLength = 1
+	}
+
+	seen := make(map[string]bool)
+	for _, term := range tokenize(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		postings := idx.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+		for key, frequency := range postings {
+			length := idx.documents[key].length
+			norm := frequency * (bm25K1 + 1) / (frequency + bm25K1*(1-bm25B+bm25B*length/avgLength))
+			scores[key] += idf * norm
+		}
+	}
+
+	return scores
+}
+
+// removeLocked drops a document; callers must hold mu for writing
+func (idx *SearchIndex) removeLocked(key string) {
+	doc, ok := idx.documents[key]
+	if !ok {
+		return
+	}
+	for term := range doc.terms {
+		if postings, ok := idx.postings[term]; ok {
+			delete(postings, key)
+			if len(postings) == 0 {
+				delete(idx.postings, term)
+			}
+		}
+	}
+	idx.totalLength -= doc.length
+	delete(idx.documents, key)
+}
+
+// indexKey identifies a recipe across sources
+func indexKey(source, id string) string {
+	return source + ":" + id
+}
+
+// addTerms adds the weighted terms of a field to a term frequency table
+func addTerms(terms map[string]float64, text string, weight float64) {
+	for _, term := range tokenize(text) {
+		terms[term] += weight
+	}
+}
+
+// tokenize lowercases text, splits it on anything but letters and digits, and drops
+// stop words and plural endings
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if indexStopWords[field] {
+			continue
+		}
+		tokens = append(tokens, units.Singular(field))
+	}
+	return tokens
+}
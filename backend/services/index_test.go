@@ -0,0 +1,96 @@
+package services
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Tomato Soup", []string{"tomato", "soup"}},
+		{"Soup with the tomatoes", []string{"soup", "tomato"}},
+		{"berries, cherries & peas", []string{"berry", "cherry", "pea"}},
+		{"hummus and couscous", []string{"hummus", "couscous"}},
+		{"grass-fed 2% milk", []string{"grass", "fed", "2", "milk"}},
+		{"the and of", []string{}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		if got := tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+// rankedKeys returns the keys of scores from the highest score to the lowest
+func rankedKeys(scores map[string]float64) []string {
+	keys := make([]string, 0, len(scores))
+	for key := range scores {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return scores[keys[i]] > scores[keys[j]] })
+	return keys
+}
+
+func TestSearchIndexScore(t *testing.T) {
+	idx := NewSearchIndex()
+	idx.Add(SearchResult{ID: "1", Source: "local", Title: "Tomato Soup", Ingredients: []string{"tomatoes", "onion"}}, true)
+	idx.Add(SearchResult{ID: "2", Source: "local", Title: "Garden Salad", Ingredients: []string{"lettuce", "tomato"}}, true)
+	idx.Add(SearchResult{ID: "3", Source: "local", Title: "Onion Bread", Description: "Serve with soup"}, true)
+	idx.Add(SearchResult{ID: "4", Source: "spoonacular", Title: "Pancakes", Ingredients: []string{"flour", "eggs"}}, false)
+
+	tests := []struct {
+		query string
+		want  []string // Matching keys, best first
+	}{
+		{"tomato", []string{"local:1", "local:2"}},
+		{"soup", []string{"local:1", "local:3"}},
+		{"Tomatoes soup", []string{"local:1", "local:2", "local:3"}},
+		{"egg", []string{"spoonacular:4"}},
+		{"chocolate", []string{}},
+		{"the", []string{}},
+	}
+	for _, tt := range tests {
+		if got := rankedKeys(idx.Score(tt.query)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Score(%q) ranks %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchIndexAddAndRemove(t *testing.T) {
+	idx := NewSearchIndex()
+	idx.Add(SearchResult{ID: "1", Source: "spoonacular", Title: "Beef Stew"}, true)
+
+	// A search summary doesn't replace full details of the same recipe
+	idx.Add(SearchResult{ID: "1", Source: "spoonacular", Title: "Stew"}, false)
+	if got := idx.Documents()[0].Title; got != "Beef Stew" {
+		t.Errorf("title after adding a summary = %q, want %q", got, "Beef Stew")
+	}
+
+	// Newer details do, and the old terms stop matching
+	idx.Add(SearchResult{ID: "1", Source: "spoonacular", Title: "Lamb Stew"}, true)
+	if idx.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", idx.Len())
+	}
+	if scores := idx.Score("beef"); len(scores) != 0 {
+		t.Errorf("Score(beef) = %v after replacing the recipe, want no matches", scores)
+	}
+	if scores := idx.Score("lamb"); len(scores) != 1 {
+		t.Errorf("Score(lamb) = %v, want one match", scores)
+	}
+
+	idx.Add(SearchResult{ID: "2", Source: "local", Title: "Lamb Curry"}, true)
+	idx.Remove("spoonacular", "1")
+	if got := rankedKeys(idx.Score("lamb")); !reflect.DeepEqual(got, []string{"local:2"}) {
+		t.Errorf("Score(lamb) after removing a recipe ranks %q, want [local:2]", got)
+	}
+
+	idx.Reset()
+	if idx.Len() != 0 || len(idx.Score("lamb")) != 0 {
+		t.Errorf("the index still holds %d recipes after Reset", idx.Len())
+	}
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"recipe-finder-backend/models"
+	"recipe-finder-backend/units"
+)
+
+// unicodeFractions maps vulgar fraction characters to their ASCII form
+var unicodeFractions = map[rune]string{
+	'½': "1/2", '⅓': "1/3", '⅔': "2/3", '¼': "1/4", '¾': "3/4",
+	'⅕': "1/5", '⅖': "2/5", '⅗': "3/5", '⅘': "4/5", '⅙': "1/6",
+	'⅚': "5/6", '⅛': "1/8", '⅜': "3/8", '⅝': "5/8", '⅞': "7/8",
+}
+
+// ingredientPrepWords start preparation notes written before the ingredient's name, as
+// in "finely chopped onions"
+var ingredientPrepWords = map[string]bool{
+	"chopped": true, "diced": true, "minced": true, "sliced": true, "grated": true,
+	"shredded": true, "crushed": true, "peeled": true, "cubed": true, "halved": true,
+	"quartered": true, "julienned": true, "softened": true, "melted": true, "beaten": true,
+	"sifted": true, "packed": true, "toasted": true, "rinsed": true, "drained": true,
+	"trimmed": true, "cooked": true, "uncooked": true, "thawed": true, "pitted": true,
+	"seeded": true, "zested": true, "juiced": true, "mashed": true,
+	"finely": true, "roughly": true, "coarsely": true, "thinly": true, "freshly": true,
+	"lightly": true, "firmly": true, "loosely": true, "well": true,
+}
+
+// ingredientNoteSuffixes end an ingredient's name with a note rather than more name
+var ingredientNoteSuffixes = []string{
+	"or more to taste", "or to taste", "to taste", "for garnish", "for serving",
+	"for frying", "for greasing", "optional", "as needed", "plus more",
+}
+
+// parentheticalPattern matches text in parentheses, e.g. a can size "(14.5 ounce)"
+var parentheticalPattern = regexp.MustCompile(`\(([^)]*)\)`)
+
+// ParseIngredientLine reads a free-text ingredient line such as "2 1/2 cups finely
+// chopped onions, divided" into its amount, unit, name and notes. It understands mixed
+// and unicode fractions, decimals, ranges ("2-3", "2 to 3", whose upper bound goes in
+// AmountMax), parenthetical sizes and notes ("1 (14.5 oz) can tomatoes"), preparation
+// words before the name and notes after a comma. Lines without an amount, like "salt to
+// taste", have a zero Amount.
+func ParseIngredientLine(line string) DetailedIngredient {
+	original := strings.TrimSpace(line)
+	ingredient := DetailedIngredient{Original: original, Meta: []string{}}
+
+	text := normalizeIngredientText(original)
+
+	// Notes after the first comma outside parentheses
+	if i := commaOutsideParens(text); i >= 0 {
+		for _, note := range strings.Split(text[i+1:], ",") {
+			if note = strings.TrimSpace(note); note != "" {
+				ingredient.Meta = append(ingredient.Meta, note)
+			}
+		}
+		text = text[:i]
+	}
+
+	// Parenthetical sizes and notes
+	var parentheticals []string
+	text = parentheticalPattern.ReplaceAllStringFunc(text, func(match string) string {
+		if inner := strings.TrimSpace(match[1 : len(match)-1]); inner != "" {
+			parentheticals = append(parentheticals, inner)
+		}
+		return " "
+	})
+
+	words := strings.Fields(text)
+	amount, amountMax, n := parseQuantity(words)
+	ingredient.Amount, ingredient.AmountMax = amount, amountMax
+	words = words[n:]
+
+	// Without an amount a unit must read "pinch of salt", so "leaf lettuce" keeps its name
+	if unit, m := lookupUnit(words); m > 0 && (n > 0 || m < len(words) && strings.EqualFold(words[m], "of")) {
+		ingredient.Unit = unit.Symbol
+		ingredient.UnitLong = unit.Label(math.Max(amount, amountMax))
+		if n == 0 {
+			ingredient.Amount = 1 // "pinch of salt" is one pinch
+		}
+		words = words[m:]
+	}
+	if len(words) > 1 && strings.EqualFold(words[0], "of") {
+		words = words[1:]
+	}
+
+	// Preparation words before the name, e.g. "finely chopped"
+	prep := 0
+	for prep < len(words)-1 && ingredientPrepWords[strings.ToLower(words[prep])] {
+		prep++
+	}
+	if prep > 0 {
+		ingredient.Meta = append([]string{strings.ToLower(strings.Join(words[:prep], " "))}, ingredient.Meta...)
+		words = words[prep:]
+	}
+
+	name := strings.ToLower(strings.Join(words, " "))
+	for _, suffix := range ingredientNoteSuffixes {
+		if strings.HasSuffix(name, " "+suffix) {
+			ingredient.Meta = append(ingredient.Meta, suffix)
+			name = strings.TrimSpace(strings.TrimSuffix(name, suffix))
+			break
+		}
+	}
+	name = strings.TrimSpace(strings.TrimSuffix(name, " or"))
+	ingredient.Meta = append(ingredient.Meta, parentheticals...)
+
+	ingredient.OriginalName = name
+	ingredient.Name = name
+	return ingredient
+}
+
+// normalizeIngredientText spells unicode fractions, fraction slashes and dashes in ASCII,
+// separating a fraction from a whole number before it: "1½" becomes "1 1/2"
+func normalizeIngredientText(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if fraction, ok := unicodeFractions[r]; ok {
+			b.WriteString(" " + fraction + " ")
+			continue
+		}
+		switch r {
+		case '⁄':
+			b.WriteRune('/')
+		case '–', '—':
+			b.WriteString(" - ")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// commaOutsideParens returns the index of the first comma not inside parentheses, or -1
+func commaOutsideParens(text string) int {
+	depth := 0
+	for i, r := range text {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseQuantity reads an amount, or a range of amounts, from the start of words,
+// returning the amounts and the number of words used
+func parseQuantity(words []string) (amount, amountMax float64, n int) {
+	amount, n = parseNumber(words)
+	if n == 0 {
+		return 0, 0, 0
+	}
+
+	// "2-3" written as one word
+	if low, high, ok := strings.Cut(words[0], "-"); ok && n == 1 && low != "" && high != "" {
+		lowValue, lowOK := parseNumberWord(low)
+		highValue, highOK := parseNumberWord(high)
+		if lowOK && highOK {
+			return lowValue, highValue, 1
+		}
+	}
+
+	// "2 - 3", "2 to 3" and "2 or 3"
+	if n < len(words) {
+		switch strings.ToLower(words[n]) {
+		case "-", "to", "or":
+			if high, m := parseNumber(words[n+1:]); m > 0 && high > amount {
+				return amount, high, n + 1 + m
+			}
+		}
+	}
+	return amount, 0, n
+}
+
+// parseNumber reads a whole number, decimal or fraction, or a whole number followed by
+// a fraction, from the start of words
+func parseNumber(words []string) (float64, int) {
+	if len(words) == 0 {
+		return 0, 0
+	}
+	first := words[0]
+	if low, _, ok := strings.Cut(first, "-"); ok && low != "" {
+		first = low // The low end of a range written as one word
+	}
+	value, ok := parseNumberWord(first)
+	if !ok {
+		return 0, 0
+	}
+	if first == words[0] && !strings.Contains(first, "/") && len(words) > 1 && strings.Contains(words[1], "/") {
+		if fraction, ok := parseNumberWord(words[1]); ok && fraction < 1 {
+			return value + fraction, 2
+		}
+	}
+	return value, 1
+}
+
+// parseNumberWord parses "2", "2.5" or "1/2"
+func parseNumberWord(word string) (float64, bool) {
+	if numerator, denominator, ok := strings.Cut(word, "/"); ok {
+		n, err1 := strconv.ParseFloat(numerator, 64)
+		d, err2 := strconv.ParseFloat(denominator, 64)
+		if err1 != nil || err2 != nil || d == 0 || !isFinite(n/d) {
+			return 0, false
+		}
+		return n / d, true
+	}
+	value, err := strconv.ParseFloat(word, 64)
+	if err != nil || value < 0 || !isFinite(value) {
+		return 0, false
+	}
+	return value, true
+}
+
+// isFinite reports whether a parsed number is neither infinite nor NaN, which ParseFloat
+// accepts as words like "inf" and "nan"
+func isFinite(value float64) bool {
+	return !math.IsInf(value, 0) && !math.IsNaN(value)
+}
+
+// lookupUnit recognises a unit at the start of words, trying two-word units first
+func lookupUnit(words []string) (units.Unit, int) {
+	if len(words) > 1 {
+		if unit, ok := units.Lookup(words[0] + " " + words[1]); ok {
+			return unit, 2
+		}
+	}
+	if len(words) > 0 {
+		if unit, ok := units.Lookup(words[0]); ok {
+			return unit, 1
+		}
+	}
+	return units.Unit{}, 0
+}
+
+// fillIngredientGaps completes an ingredient from its original line where the provider
+// left fields empty. Fields the provider did set are kept.
+func fillIngredientGaps(ingredient *DetailedIngredient) {
+	if ingredient.Original == "" || (ingredient.Name != "" && ingredient.Amount != 0 && ingredient.Unit != "") {
+		return
+	}
+	parsed := ParseIngredientLine(ingredient.Original)
+	if ingredient.Name == "" {
+		ingredient.Name = parsed.Name
+	}
+	if ingredient.OriginalName == "" {
+		ingredient.OriginalName = parsed.OriginalName
+	}
+	if ingredient.Amount == 0 && parsed.Amount != 0 {
+		ingredient.Amount, ingredient.AmountMax = parsed.Amount, parsed.AmountMax
+		if ingredient.Unit == "" {
+			ingredient.Unit, ingredient.UnitLong = parsed.Unit, parsed.UnitLong
+		}
+	}
+	if len(ingredient.Meta) == 0 {
+		ingredient.Meta = parsed.Meta
+	}
+}
+
+// ValidateIngredientLines checks that every ingredient line of a recipe names an
+// ingredient, not just an amount such as "2 cups"
+func ValidateIngredientLines(lines []string) error {
+	v := &models.ValidationError{}
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if ParseIngredientLine(line).Name == "" {
+			if v.Fields == nil {
+				v.Fields = make(map[string]string)
+			}
+			v.Fields[fmt.Sprintf("ingredients[%d]", i)] = fmt.Sprintf("%q names no ingredient", line)
+		}
+	}
+	if len(v.Fields) == 0 {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"recipe-finder-backend/models"
+)
+
+func TestParseIngredientLine(t *testing.T) {
+	tests := []struct {
+		line      string
+		amount    float64
+		amountMax float64
+		unit      string
+		name      string
+		meta      []string
+	}{
+		{"2 cups flour", 2, 0, "cup", "flour", []string{}},
+		{"2 1/2 cups finely chopped onions, divided", 2.5, 0, "cup", "onions", []string{"finely chopped", "divided"}},
+		{"1½ tbsp olive oil", 1.5, 0, "tbsp", "olive oil", []string{}},
+		{"¾ tsp salt", 0.75, 0, "tsp", "salt", []string{}},
+		{"0.5 kg potatoes", 0.5, 0, "kg", "potatoes", []string{}},
+		{"2-3 cloves garlic, minced", 2, 3, "clove", "garlic", []string{"minced"}},
+		{"2 to 3 tablespoons butter", 2, 3, "tbsp", "butter", []string{}},
+		{"1 (14.5 oz) can diced tomatoes", 1, 0, "can", "tomatoes", []string{"diced", "14.5 oz"}},
+		{"3 eggs", 3, 0, "", "eggs", []string{}},
+		{"salt to taste", 0, 0, "", "salt", []string{"to taste"}},
+		{"pinch of salt", 1, 0, "pinch", "salt", []string{}},
+		{"leaf lettuce", 0, 0, "", "leaf lettuce", []string{}},
+		{"2 cups", 2, 0, "cup", "", []string{}},
+		{"Infinity cups flour", 0, 0, "", "infinity cups flour", []string{}},
+		{"nan eggs", 0, 0, "", "nan eggs", []string{}},
+		{"inf/2 tbsp sugar", 0, 0, "", "inf/2 tbsp sugar", []string{}},
+		{"1 inf/1 tsp salt", 1, 0, "", "inf/1 tsp salt", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got := ParseIngredientLine(tt.line)
+			if got.Amount != tt.amount || got.AmountMax != tt.amountMax {
+				t.Errorf("amount = %v-%v, want %v-%v", got.Amount, got.AmountMax, tt.amount, tt.amountMax)
+			}
+			if got.Unit != tt.unit {
+				t.Errorf("unit = %q, want %q", got.Unit, tt.unit)
+			}
+			if got.Name != tt.name {
+				t.Errorf("name = %q, want %q", got.Name, tt.name)
+			}
+			if !reflect.DeepEqual(got.Meta, tt.meta) {
+				t.Errorf("meta = %q, want %q", got.Meta, tt.meta)
+			}
+			if got.Original != tt.line {
+				t.Errorf("original = %q, want the line", got.Original)
+			}
+		})
+	}
+}
+
+func TestValidateIngredientLines(t *testing.T) {
+	tests := []struct {
+		lines   []string
+		invalid []string // Fields expected in the validation error
+	}{
+		{[]string{"2 cups flour", "salt"}, nil},
+		{[]string{"2 cups flour", "", "1 tsp"}, []string{"ingredients[2]"}},
+	}
+	for _, tt := range tests {
+		err := ValidateIngredientLines(tt.lines)
+		if len(tt.invalid) == 0 {
+			if err != nil {
+				t.Errorf("ValidateIngredientLines(%q) = %v, want nil", tt.lines, err)
+			}
+			continue
+		}
+		var validationErr *models.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("ValidateIngredientLines(%q) = %v, want a validation error", tt.lines, err)
+			continue
+		}
+		for _, field := range tt.invalid {
+			if _, ok := validationErr.Fields[field]; !ok {
+				t.Errorf("ValidateIngredientLines(%q) = %v, want an error for %s", tt.lines, err, field)
+			}
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"recipe-finder-backend/models"
+)
+
+// ErrRecipeNotFound is returned when a requested recipe does not exist
+var ErrRecipeNotFound = errors.New("recipe not found")
+
+// uuidPattern matches the UUIDs assigned to user-authored records: recipes and pantry items
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// LocalRecipeStorage represents the storage structure for a user-authored recipe
+type LocalRecipeStorage struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	Recipe        *models.Recipe  `json:"recipe"`
+	Metadata      StorageMetadata `json:"metadata"`
+}
+
+// NewLocalRecipeID generates a random UUID (version 4) for a user-authored recipe
+func NewLocalRecipeID() (string, error) {
+	id, err := newUUID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate recipe ID: %v", err)
+	}
+	return id, nil
+}
+
+// IsLocalRecipeID reports whether id has the format of a user-authored recipe ID
+func IsLocalRecipeID(id string) bool {
+	return uuidPattern.MatchString(id)
+}
+
+// newUUID generates a random UUID (version 4)
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// SaveLocalRecipe creates or replaces a user-authored recipe
+func (s *StorageService) SaveLocalRecipe(recipe *models.Recipe) error {
+	s.localRecipeMu.Lock()
+	defer s.localRecipeMu.Unlock()
+
+	return s.saveLocalRecipe(recipe)
+}
+
+// saveLocalRecipe writes a user-authored recipe; callers must hold localRecipeMu
+func (s *StorageService) saveLocalRecipe(recipe *models.Recipe) error {
+	if !IsLocalRecipeID(recipe.ID) {
+		return fmt.Errorf("invalid local recipe ID: %q", recipe.ID)
+	}
+
+	filename := recordFilename(KindLocalRecipe, recipe.ID)
+	storage := LocalRecipeStorage{
+		SchemaVersion: SchemaVersion(KindLocalRecipe),
+		Recipe:        recipe,
+		Metadata: StorageMetadata{
+			Timestamp: time.Now(),
+			RecipeID:  recipe.ID,
+			Source:    "local",
+			Filename:  filename,
+		},
+	}
+
+	if err := s.putJSON(KindLocalRecipe, recipe.ID, storage); err != nil {
+		return fmt.Errorf("failed to write local recipe: %v", err)
+	}
+
+	s.index.Add(searchResultFromLocal(recipe), true)
+
+	fmt.Printf("💾 Saved local recipe %s to %s\n", recipe.ID, filename)
+	return nil
+}
+
+// LoadLocalRecipe loads a user-authored recipe, returning ErrRecipeNotFound if it does not exist
+func (s *StorageService) LoadLocalRecipe(id string) (*models.Recipe, error) {
+	if !IsLocalRecipeID(id) {
+		return nil, ErrRecipeNotFound
+	}
+
+	var storage LocalRecipeStorage
+	if err := s.getJSON(KindLocalRecipe, id, &storage); err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return nil, ErrRecipeNotFound
+		}
+		return nil, fmt.Errorf("failed to read local recipe: %v", err)
+	}
+	if storage.Recipe == nil {
+		return nil, fmt.Errorf("local recipe %s has no recipe", id)
+	}
+
+	return storage.Recipe, nil
+}
+
+// UpdateLocalRecipe loads a user-authored recipe, lets update change it and saves it,
+// returning ErrRecipeNotFound if it does not exist. Local recipes stay locked
+// throughout, so two changes to the same recipe can't overwrite each other.
+func (s *StorageService) UpdateLocalRecipe(id string, update func(recipe *models.Recipe)) (*models.Recipe, error) {
+	s.localRecipeMu.Lock()
+	defer s.localRecipeMu.Unlock()
+
+	recipe, err := s.LoadLocalRecipe(id)
+	if err != nil {
+		return nil, err
+	}
+	update(recipe)
+	recipe.ID = id
+	if err := s.saveLocalRecipe(recipe); err != nil {
+		return nil, err
+	}
+	return recipe, nil
+}
+
+// ListLocalRecipes returns all user-authored recipes, newest first
+func (s *StorageService) ListLocalRecipes() ([]*models.Recipe, error) {
+	records, err := s.store.List(KindLocalRecipe)
+	if err != nil {
+		return nil, err
+	}
+
+	recipes := make([]*models.Recipe, 0, len(records))
+	for _, record := range records {
+		var storage LocalRecipeStorage
+		if err := s.decodeRecord(record, &storage); err != nil || storage.Recipe == nil {
+			continue // Skip records we can't parse
+		}
+		recipes = append(recipes, storage.Recipe)
+	}
+
+	sort.Slice(recipes, func(i, j int) bool {
+		return recipes[i].CreatedAt.After(recipes[j].CreatedAt)
+	})
+	return recipes, nil
+}
+
+// DeleteLocalRecipe removes a user-authored recipe, returning ErrRecipeNotFound if it does not exist
+func (s *StorageService) DeleteLocalRecipe(id string) error {
+	if !IsLocalRecipeID(id) {
+		return ErrRecipeNotFound
+	}
+
+	s.localRecipeMu.Lock()
+	defer s.localRecipeMu.Unlock()
+
+	if err := s.store.Delete(KindLocalRecipe, id); err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return ErrRecipeNotFound
+		}
+		return fmt.Errorf("failed to delete local recipe: %v", err)
+	}
+
+	s.index.Remove("local", id)
+
+	fmt.Printf("🗑️  Deleted local recipe %s\n", id)
+	return nil
+}
+
+// RecipeDetailsFromLocal presents a user-authored recipe in the same shape as Spoonacular
+// recipe details, so GET /api/v1/recipes/{id} serves both
+func RecipeDetailsFromLocal(recipe *models.Recipe) *RecipeDetails {
+	ingredients := make([]DetailedIngredient, 0, len(recipe.Ingredients))
+	for _, line := range recipe.Ingredients {
+		ingredients = append(ingredients, ParseIngredientLine(line))
+	}
+
+	instructions := make([]Instruction, 0, len(recipe.Instructions))
+	for i, step := range recipe.Instructions {
+		instructions = append(instructions, Instruction{Number: i + 1, Step: step})
+	}
+
+	dishTypes := []string{}
+	if recipe.Category != "" {
+		dishTypes = append(dishTypes, recipe.Category)
+	}
+
+	return &RecipeDetails{
+		ID:             recipe.ID,
+		Title:          recipe.Title,
+		Description:    recipe.Description,
+		Summary:        recipe.Description,
+		Ingredients:    ingredients,
+		Instructions:   instructions,
+		PrepTime:       formatMinutes(recipe.PrepTime),
+		CookTime:       formatMinutes(recipe.CookTime),
+		TotalTime:      formatMinutes(recipe.PrepTime + recipe.CookTime),
+		ReadyInMinutes: recipe.PrepTime + recipe.CookTime,
+		Servings:       recipe.Servings,
+		ImageURL:       recipe.ImageURL,
+		Cuisines:       []string{},
+		DishTypes:      dishTypes,
+		Diets:          []string{},
+		Occasions:      []string{},
+	}
+}
+
+// formatMinutes writes a duration the way recipe details do, e.g. "25 min"; unknown
+// durations are left empty
+func formatMinutes(minutes int) string {
+	if minutes <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d min", minutes)
+}
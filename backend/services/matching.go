@@ -0,0 +1,448 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"recipe-finder-backend/units"
+)
+
+// MatchKind says how a user's ingredient satisfies a recipe ingredient
+type MatchKind string
+
+// Match kinds; those from the tables here strongest first
+const (
+	MatchExact    MatchKind = "exact"    // Same ingredient, ignoring case, plurals and preparation words
+	MatchSynonym  MatchKind = "synonym"  // Another name for it, e.g. scallion and green onion
+	MatchSpecific MatchKind = "specific" // The user's is a kind of it, e.g. chicken breast for chicken
+	MatchFamily   MatchKind = "family"   // The user's belongs to its family, e.g. cheddar for cheese
+	MatchGeneral  MatchKind = "general"  // The recipe wants a particular kind, e.g. chicken for chicken breast
+	MatchBroad    MatchKind = "broad"    // The user's is a family the recipe's belongs to, e.g. cheese for feta
+	MatchUpstream MatchKind = "upstream" // Spoonacular matched it though the tables here don't
+	MatchNone     MatchKind = "none"
+)
+
+// matchScores weighs each kind of match from 0 to 1
+var matchScores = map[MatchKind]float64{
+	MatchExact:    1,
+	MatchSynonym:  0.95,
+	MatchSpecific: 0.9,
+	MatchFamily:   0.85,
+	MatchGeneral:  0.75,
+	MatchBroad:    0.4, // Below MatchThreshold: a broad match only hints the user may have it
+	MatchUpstream: 0.9,
+	MatchNone:     0,
+}
+
+// MatchThreshold is the score from which a user's ingredient counts as used by a recipe
+const MatchThreshold = 0.5
+
+// IngredientMatch explains how well one recipe ingredient is covered by the user's ingredients
+type IngredientMatch struct {
+	Ingredient  string    `json:"ingredient"`          // The recipe's ingredient
+	MatchedBy   string    `json:"matchedBy,omitempty"` // The user's ingredient covering it
+	Kind        MatchKind `json:"kind"`
+	Score       float64   `json:"score"`
+	Explanation string    `json:"explanation"`
+}
+
+// Matched reports whether the ingredient counts as one the user has
+func (m IngredientMatch) Matched() bool {
+	return m.Score >= MatchThreshold
+}
+
+// RecipeMatch scores a recipe against the user's ingredients
+type RecipeMatch struct {
+	Score       float64           // Mean of the per-ingredient scores, 0 to 1
+	MatchCount  int               // The user's ingredients the recipe uses
+	Ingredients []IngredientMatch // One per recipe ingredient, in recipe order
+	Unused      []string          // The user's ingredients the recipe doesn't use
+}
+
+// ingredientSynonyms maps alternative names to the one used for matching. Keys and
+// values are normalized: lowercase and singular.
+var ingredientSynonyms = map[string]string{
+	"scallion":             "green onion",
+	"spring onion":         "green onion",
+	"coriander":            "cilantro",
+	"coriander leaf":       "cilantro",
+	"garbanzo bean":        "chickpea",
+	"garbanzo":             "chickpea",
+	"aubergine":            "eggplant",
+	"courgette":            "zucchini",
+	"capsicum":             "bell pepper",
+	"sweet pepper":         "bell pepper",
+	"prawn":                "shrimp",
+	"rocket":               "arugula",
+	"cornflour":            "cornstarch",
+	"corn starch":          "cornstarch",
+	"plain flour":          "all purpose flour",
+	"all-purpose flour":    "all purpose flour",
+	"double cream":         "heavy cream",
+	"whipping cream":       "heavy cream",
+	"heavy whipping cream": "heavy cream",
+	"icing sugar":          "powdered sugar",
+	"confectioner sugar":   "powdered sugar",
+	"confectioners sugar":  "powdered sugar",
+	"caster sugar":         "superfine sugar",
+	"bicarbonate of soda":  "baking soda",
+	"beef mince":           "ground beef",
+	"string bean":          "green bean",
+	"mangetout":            "snow pea",
+	"beetroot":             "beet",
+	"swede":                "rutabaga",
+	"maize":                "corn",
+	"chilli":               "chili",
+	"chile":                "chili",
+	"yoghurt":              "yogurt",
+}
+
+// ingredientFamilies maps an ingredient to the broader one it can stand in for
+var ingredientFamilies = map[string]string{
+	"cheddar":     "cheese",
+	"mozzarella":  "cheese",
+	"parmesan":    "cheese",
+	"feta":        "cheese",
+	"gouda":       "cheese",
+	"brie":        "cheese",
+	"ricotta":     "cheese",
+	"gruyere":     "cheese",
+	"spaghetti":   "pasta",
+	"penne":       "pasta",
+	"fusilli":     "pasta",
+	"linguine":    "pasta",
+	"fettuccine":  "pasta",
+	"macaroni":    "pasta",
+	"rigatoni":    "pasta",
+	"lasagna":     "pasta",
+	"farfalle":    "pasta",
+	"salmon":      "fish",
+	"cod":         "fish",
+	"tuna":        "fish",
+	"tilapia":     "fish",
+	"halibut":     "fish",
+	"haddock":     "fish",
+	"trout":       "fish",
+	"shrimp":      "shellfish",
+	"crab":        "shellfish",
+	"lobster":     "shellfish",
+	"scallop":     "shellfish",
+	"mussel":      "shellfish",
+	"clam":        "shellfish",
+	"steak":       "beef",
+	"sirloin":     "beef",
+	"brisket":     "beef",
+	"bacon":       "pork",
+	"ham":         "pork",
+	"pancetta":    "pork",
+	"prosciutto":  "pork",
+	"shallot":     "onion",
+	"leek":        "onion",
+	"green onion": "onion",
+	"basil":       "herb",
+	"parsley":     "herb",
+	"cilantro":    "herb",
+	"thyme":       "herb",
+	"rosemary":    "herb",
+	"oregano":     "herb",
+	"dill":        "herb",
+	"mint":        "herb",
+	"sage":        "herb",
+	"spinach":     "leafy green",
+	"kale":        "leafy green",
+	"chard":       "leafy green",
+	"arugula":     "leafy green",
+	"lettuce":     "leafy green",
+	"lemon":       "citrus",
+	"lime":        "citrus",
+	"orange":      "citrus",
+	"grapefruit":  "citrus",
+	"strawberry":  "berry",
+	"blueberry":   "berry",
+	"raspberry":   "berry",
+	"blackberry":  "berry",
+	"cranberry":   "berry",
+	"almond":      "nut",
+	"walnut":      "nut",
+	"pecan":       "nut",
+	"cashew":      "nut",
+	"hazelnut":    "nut",
+	"pistachio":   "nut",
+	"rice noodle": "noodle",
+	"egg noodle":  "noodle",
+	"udon":        "noodle",
+	"soba":        "noodle",
+	"ramen":       "noodle",
+	"baguette":    "bread",
+	"ciabatta":    "bread",
+	"sourdough":   "bread",
+	"tortilla":    "flatbread",
+	"pita":        "flatbread",
+	"naan":        "flatbread",
+	"flatbread":   "bread",
+	"chicken":     "poultry",
+	"turkey":      "poultry",
+	"duck":        "poultry",
+	"jalapeno":    "chili",
+	"serrano":     "chili",
+	"habanero":    "chili",
+}
+
+// preparationWords describe how an ingredient is prepared or sold rather than what it is
+var preparationWords = map[string]bool{
+	"fresh": true, "freshly": true, "chopped": true, "diced": true, "minced": true,
+	"sliced": true, "grated": true, "shredded": true, "crushed": true, "peeled": true,
+	"finely": true, "roughly": true, "thinly": true, "large": true, "small": true,
+	"medium": true, "boneless": true, "skinless": true, "organic": true, "raw": true,
+	"dried": true, "extra": true, "virgin": true, "cooked": true, "uncooked": true,
+	"frozen": true, "canned": true, "ripe": true, "unsalted": true, "salted": true,
+	"low": true, "sodium": true, "reduced": true, "fat": true,
+}
+
+// derivedProducts are words that turn an ingredient into a different product: garlic
+// powder is no use to someone holding garlic, so containment doesn't cross them
+var derivedProducts = map[string]bool{
+	"broth": true, "stock": true, "powder": true, "sauce": true, "paste": true,
+	"extract": true, "bouillon": true, "seasoning": true, "oil": true, "vinegar": true,
+	"butter": true, "flour": true, "starch": true, "syrup": true, "jam": true,
+	"jelly": true, "milk": true, "cream": true, "noodle": true,
+	"bread": true, "soup": true, "salsa": true, "ketchup": true,
+}
+
+// distinctCompounds are ingredients whose name contains another ingredient's although
+// they are unrelated to it
+var distinctCompounds = map[string]bool{
+	"peanut butter": true, "apple butter": true, "cocoa butter": true,
+	"cream cheese": true, "ice cream": true, "sour cream": true, "cream of tartar": true,
+	"coconut milk": true, "coconut cream": true, "almond milk": true, "soy milk": true,
+	"oat milk": true, "butter bean": true, "egg noodle": true, "bell pepper": true,
+	"sweet potato": true, "soy sauce": true, "fish sauce": true, "hot sauce": true,
+	"hoisin sauce": true, "oyster sauce": true, "baking soda": true, "baking powder": true,
+	"green bean": true,
+}
+
+// IngredientMatcher decides whether a user's ingredients cover a recipe's, tolerating
+// plurals, preparation words, synonyms, more or less specific names and ingredient
+// families
+type IngredientMatcher struct {
+	synonyms map[string]string
+	families map[string]string
+}
+
+// NewIngredientMatcher returns a matcher with the built-in synonym and family tables
+func NewIngredientMatcher() *IngredientMatcher {
+	return &IngredientMatcher{synonyms: ingredientSynonyms, families: ingredientFamilies}
+}
+
+// defaultMatcher is shared by recipe searches; it holds no mutable state
+var defaultMatcher = NewIngredientMatcher()
+
+// MatchIngredients scores a recipe's ingredients against the user's with the built-in tables
+func MatchIngredients(userIngredients, recipeIngredients []string) RecipeMatch {
+	return defaultMatcher.MatchRecipe(userIngredients, recipeIngredients)
+}
+
+// MatchRecipe finds the best of the user's ingredients for each recipe ingredient.
+// Score is the mean over recipe ingredients, so it says how much of the recipe the user
+// can cover; MatchCount counts the user's ingredients that cover at least one of them.
+func (m *IngredientMatcher) MatchRecipe(userIngredients, recipeIngredients []string) RecipeMatch {
+	user := make([]matchTerm, len(userIngredients))
+	for i, ingredient := range userIngredients {
+		user[i] = m.term(ingredient)
+	}
+
+	result := RecipeMatch{Ingredients: make([]IngredientMatch, 0, len(recipeIngredients)), Unused: []string{}}
+	used := make([]bool, len(userIngredients))
+	total := 0.0
+	for _, recipeIngredient := range recipeIngredients {
+		recipe := m.term(recipeIngredient)
+		best := IngredientMatch{Ingredient: recipeIngredient, Kind: MatchNone,
+			Explanation: fmt.Sprintf("none of your ingredients covers %s", recipeIngredient)}
+		bestUser := -1
+		for i := range user {
+			if match := m.compare(user[i], recipe); match.Score > best.Score {
+				best, bestUser = match, i
+			}
+		}
+		if bestUser >= 0 && best.Matched() {
+			used[bestUser] = true
+		}
+		total += best.Score
+		result.Ingredients = append(result.Ingredients, best)
+	}
+
+	for i, ingredient := range userIngredients {
+		if used[i] {
+			result.MatchCount++
+		} else {
+			result.Unused = append(result.Unused, ingredient)
+		}
+	}
+	if len(recipeIngredients) > 0 {
+		result.Score = math.Round(total/float64(len(recipeIngredients))*100) / 100
+	}
+	return result
+}
+
+// Match scores how well one user ingredient covers one recipe ingredient
+func (m *IngredientMatcher) Match(userIngredient, recipeIngredient string) IngredientMatch {
+	match := m.compare(m.term(userIngredient), m.term(recipeIngredient))
+	if match.Kind == MatchNone {
+		match.Explanation = fmt.Sprintf("%s is not %s", userIngredient, recipeIngredient)
+	}
+	return match
+}
+
+// matchTerm is an ingredient name prepared for comparison
+type matchTerm struct {
+	original  string
+	plain     string   // Normalized name as written
+	canonical string   // Normalized name with synonyms resolved
+	tokens    []string // Words of the canonical name
+}
+
+// term normalizes an ingredient name: lowercase, singular words, no punctuation or
+// preparation words, and synonyms replaced by their canonical names
+func (m *IngredientMatcher) term(name string) matchTerm {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	})
+	words := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if !preparationWords[field] {
+			words = append(words, units.Singular(field))
+		}
+	}
+	if len(words) == 0 {
+		// Keep names made only of preparation words, e.g. "extra"
+		for _, field := range fields {
+			words = append(words, units.Singular(field))
+		}
+	}
+
+	plain := strings.Join(words, " ")
+	canonical, ok := m.synonyms[plain]
+	if !ok {
+		canonical = m.replaceSynonyms(words)
+	}
+	return matchTerm{original: name, plain: plain, canonical: canonical, tokens: strings.Fields(canonical)}
+}
+
+// replaceSynonyms swaps synonyms inside a longer name, e.g. "chopped scallion greens"
+func (m *IngredientMatcher) replaceSynonyms(words []string) string {
+	replaced := make([]string, 0, len(words))
+	for i := 0; i < len(words); i++ {
+		if i+1 < len(words) {
+			if synonym, ok := m.synonyms[words[i]+" "+words[i+1]]; ok {
+				replaced = append(replaced, synonym)
+				i++
+				continue
+			}
+		}
+		if synonym, ok := m.synonyms[words[i]]; ok {
+			replaced = append(replaced, synonym)
+			continue
+		}
+		replaced = append(replaced, words[i])
+	}
+	return strings.Join(replaced, " ")
+}
+
+// compare works out the strongest relation between a user's and a recipe's ingredient
+func (m *IngredientMatcher) compare(user, recipe matchTerm) IngredientMatch {
+	match := IngredientMatch{Ingredient: recipe.original, MatchedBy: user.original, Kind: MatchNone}
+	if user.canonical == "" || recipe.canonical == "" {
+		return match
+	}
+
+	switch {
+	case user.canonical == recipe.canonical:
+		if user.plain == recipe.plain {
+			match.Kind = MatchExact
+			match.Explanation = fmt.Sprintf("%s and %s are the same ingredient", user.original, recipe.original)
+		} else {
+			match.Kind = MatchSynonym
+			match.Explanation = fmt.Sprintf("%s is another name for %s", user.original, recipe.original)
+		}
+	case containsTerm(user, recipe):
+		match.Kind = MatchSpecific
+		match.Explanation = fmt.Sprintf("%s is a kind of %s", user.original, recipe.original)
+	case m.inFamily(user.canonical, recipe.canonical):
+		match.Kind = MatchFamily
+		match.Explanation = fmt.Sprintf("%s is a kind of %s", user.original, recipe.original)
+	case containsTerm(recipe, user):
+		match.Kind = MatchGeneral
+		match.Explanation = fmt.Sprintf("the recipe asks for %s specifically; you have %s", recipe.original, user.original)
+	case m.inFamily(recipe.canonical, user.canonical):
+		match.Kind = MatchBroad
+		match.Explanation = fmt.Sprintf("%s is a kind of %s, which you may have", recipe.original, user.original)
+	}
+	match.Score = matchScores[match.Kind]
+	return match
+}
+
+// containsTerm reports whether specific names a kind of general: every word of general
+// appears in specific ("chicken breast" contains "chicken"), the extra words don't make
+// it a different product ("chicken broth" is not chicken) and specific isn't a known
+// unrelated compound ("peanut butter" is not butter)
+func containsTerm(specific, general matchTerm) bool {
+	if len(specific.tokens) <= len(general.tokens) || distinctCompounds[specific.canonical] {
+		return false
+	}
+	words := make(map[string]bool, len(specific.tokens))
+	for _, token := range specific.tokens {
+		words[token] = true
+	}
+	for _, token := range general.tokens {
+		if !words[token] {
+			return false
+		}
+	}
+
+	generalWords := make(map[string]bool, len(general.tokens))
+	for _, token := range general.tokens {
+		generalWords[token] = true
+	}
+	for _, token := range specific.tokens {
+		if !generalWords[token] && derivedProducts[token] {
+			return false
+		}
+	}
+	return true
+}
+
+// inFamily reports whether child belongs to parent's family, directly or through
+// broader ones (chicken is poultry)
+func (m *IngredientMatcher) inFamily(child, parent string) bool {
+	for seen := 0; seen < 8; seen++ {
+		next, ok := m.familyOf(child)
+		if !ok {
+			return false
+		}
+		if next == parent {
+			return true
+		}
+		child = next
+	}
+	return false
+}
+
+// familyOf returns the family of an ingredient, looking at the whole name first and
+// then at its last word ("aged cheddar" is a cheddar)
+func (m *IngredientMatcher) familyOf(name string) (string, bool) {
+	if family, ok := m.families[name]; ok {
+		return family, true
+	}
+	words := strings.Fields(name)
+	if len(words) > 1 {
+		if family, ok := m.families[strings.Join(words[len(words)-2:], " ")]; ok {
+			return family, true
+		}
+		if family, ok := m.families[words[len(words)-1]]; ok {
+			return family, true
+		}
+	}
+	return "", false
+}
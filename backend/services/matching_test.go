@@ -0,0 +1,127 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIngredientMatcherMatch(t *testing.T) {
+	tests := []struct {
+		user, recipe string
+		want         MatchKind
+		wantMatched  bool
+	}{
+		{"tomato", "tomatoes", MatchExact, true},
+		{"Tomatoes", "fresh diced tomatoes", MatchExact, true},
+		{"scallions", "green onions", MatchSynonym, true},
+		{"chickpeas", "garbanzo beans", MatchSynonym, true},
+		{"chicken breast", "chicken", MatchSpecific, true},
+		{"cheddar", "cheese", MatchFamily, true},
+		{"spaghetti", "pasta", MatchFamily, true},
+		{"chicken", "boneless chicken thighs", MatchGeneral, true},
+		{"cheese", "feta", MatchBroad, false},
+		{"fish", "salmon", MatchBroad, false},
+		{"garlic", "garlic powder", MatchNone, false},
+		{"chicken", "chicken broth", MatchNone, false},
+		{"butter", "peanut butter", MatchNone, false},
+		{"cream", "sour cream", MatchNone, false},
+		{"rice", "pasta", MatchNone, false},
+	}
+	matcher := NewIngredientMatcher()
+	for _, tt := range tests {
+		t.Run(tt.user+"/"+tt.recipe, func(t *testing.T) {
+			got := matcher.Match(tt.user, tt.recipe)
+			if got.Kind != tt.want {
+				t.Errorf("kind = %s, want %s (%s)", got.Kind, tt.want, got.Explanation)
+			}
+			if got.Score != matchScores[tt.want] {
+				t.Errorf("score = %v, want %v", got.Score, matchScores[tt.want])
+			}
+			if got.Matched() != tt.wantMatched {
+				t.Errorf("matched = %v, want %v", got.Matched(), tt.wantMatched)
+			}
+			if got.Explanation == "" {
+				t.Errorf("no explanation")
+			}
+		})
+	}
+}
+
+func TestMatchScoresAgainstThreshold(t *testing.T) {
+	for kind, score := range matchScores {
+		wantMatched := kind != MatchBroad && kind != MatchNone
+		if matched := score >= MatchThreshold; matched != wantMatched {
+			t.Errorf("%s scores %v: counted as used %v, want %v", kind, score, matched, wantMatched)
+		}
+	}
+}
+
+func TestMatchIngredients(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       []string
+		recipe     []string
+		wantScore  float64
+		wantCount  int
+		wantUnused []string
+		wantKinds  []MatchKind
+	}{
+		{
+			name:       "all covered",
+			user:       []string{"tomato", "basil"},
+			recipe:     []string{"tomatoes", "fresh basil"},
+			wantScore:  1,
+			wantCount:  2,
+			wantUnused: []string{},
+			wantKinds:  []MatchKind{MatchExact, MatchExact},
+		},
+		{
+			name:       "partly covered",
+			user:       []string{"cheddar", "rice", "kale"},
+			recipe:     []string{"cheese", "pasta"},
+			wantScore:  0.43,
+			wantCount:  1,
+			wantUnused: []string{"rice", "kale"},
+			wantKinds:  []MatchKind{MatchFamily, MatchNone},
+		},
+		{
+			name:       "broad matches don't use the ingredient",
+			user:       []string{"cheese"},
+			recipe:     []string{"feta", "olives"},
+			wantScore:  0.2,
+			wantCount:  0,
+			wantUnused: []string{"cheese"},
+			wantKinds:  []MatchKind{MatchBroad, MatchNone},
+		},
+		{
+			name:       "no recipe ingredients",
+			user:       []string{"egg"},
+			recipe:     nil,
+			wantScore:  0,
+			wantCount:  0,
+			wantUnused: []string{"egg"},
+			wantKinds:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MatchIngredients(tt.user, tt.recipe)
+			if got.Score != tt.wantScore {
+				t.Errorf("score = %v, want %v", got.Score, tt.wantScore)
+			}
+			if got.MatchCount != tt.wantCount {
+				t.Errorf("match count = %d, want %d", got.MatchCount, tt.wantCount)
+			}
+			if !reflect.DeepEqual(got.Unused, tt.wantUnused) {
+				t.Errorf("unused = %q, want %q", got.Unused, tt.wantUnused)
+			}
+			var kinds []MatchKind
+			for _, match := range got.Ingredients {
+				kinds = append(kinds, match.Kind)
+			}
+			if !reflect.DeepEqual(kinds, tt.wantKinds) {
+				t.Errorf("kinds = %v, want %v", kinds, tt.wantKinds)
+			}
+		})
+	}
+}
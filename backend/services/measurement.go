@@ -0,0 +1,37 @@
+package services
+
+import (
+	"recipe-finder-backend/units"
+)
+
+// ConvertRecipeDetails returns a copy of a recipe's details with ingredient amounts in a
+// measurement system and the temperatures in its instructions converted to match. In
+// metric, dry ingredients with a known density are weighed in grams. Counted amounts,
+// like cans and cloves, and amounts already in the system are left as written. The
+// details passed in are not changed.
+func ConvertRecipeDetails(details *RecipeDetails, system units.System) *RecipeDetails {
+	converted := *details
+	if system == units.Original {
+		return &converted
+	}
+
+	converted.Ingredients = make([]DetailedIngredient, len(details.Ingredients))
+	for i, ingredient := range details.Ingredients {
+		unit, known := units.Lookup(ingredient.Unit)
+		if ingredient.Amount > 0 && known && unit.Dimension != units.Count && unit.System != system {
+			name := ingredient.Name
+			ingredient = requantify(ingredient, func(q units.Quantity) units.Quantity {
+				return units.ToSystem(q, system, name)
+			})
+		}
+		converted.Ingredients[i] = ingredient
+	}
+
+	converted.Instructions = make([]Instruction, len(details.Instructions))
+	for i, instruction := range details.Instructions {
+		instruction.Step = units.ConvertTemperatures(instruction.Step, system)
+		converted.Instructions[i] = instruction
+	}
+	converted.Units = string(system)
+	return &converted
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// schemaVersionKey is the JSON key holding the schema version of every stored record
+const schemaVersionKey = "schemaVersion"
+
+// Migration upgrades stored records of one kind from schema version From to From+1.
+// Apply edits the decoded JSON document in place.
+type Migration struct {
+	Kind        RecordKind
+	From        int
+	Description string
+	Apply       func(doc map[string]interface{}) error
+}
+
+// migrations lists every schema upgrade, in order. Records written before schema
+// versioning existed are version 0. To change a stored format, bump the struct,
+// then append a migration here that rewrites older documents into the new shape.
+var migrations = []Migration{
+	{
+		Kind:        KindRecipes,
+		From:        0,
+		Description: "add schema version",
+		Apply:       func(doc map[string]interface{}) error { return nil },
+	},
+	{
+		Kind:        KindIngredients,
+		From:        0,
+		Description: "add schema version",
+		Apply:       func(doc map[string]interface{}) error { return nil },
+	},
+	{
+		Kind:        KindRecipeDetails,
+		From:        0,
+		Description: "use camelCase keys (recipe_details -> recipeDetails, recipeID -> recipeId)",
+		Apply: func(doc map[string]interface{}) error {
+			renameKey(doc, "recipe_details", "recipeDetails")
+			if metadata, ok := doc["metadata"].(map[string]interface{}); ok {
+				renameKey(metadata, "recipeID", "recipeId")
+			}
+			return nil
+		},
+	},
+	{
+		Kind:        KindLocalRecipe,
+		From:        0,
+		Description: "use camelCase metadata keys (recipeID -> recipeId)",
+		Apply: func(doc map[string]interface{}) error {
+			if metadata, ok := doc["metadata"].(map[string]interface{}); ok {
+				renameKey(metadata, "recipeID", "recipeId")
+			}
+			return nil
+		},
+	},
+	{
+		Kind:        KindQuota,
+		From:        0,
+		Description: "add schema version",
+		Apply:       func(doc map[string]interface{}) error { return nil },
+	},
+}
+
+// MigrationReport summarizes a MigrateStore run
+type MigrationReport struct {
+	Checked  map[RecordKind]int `json:"checked"`
+	Migrated map[RecordKind]int `json:"migrated"`
+	Failed   map[RecordKind]int `json:"failed"`
+}
+
+// SchemaVersion returns the current schema version of a record kind
+func SchemaVersion(kind RecordKind) int {
+	version := 0
+	for _, m := range migrations {
+		if m.Kind == kind && m.From+1 > version {
+			version = m.From + 1
+		}
+	}
+	return version
+}
+
+// MigrateRecord upgrades the JSON document of a record to the current schema version
+// of its kind. It returns the upgraded data and whether anything changed.
+func MigrateRecord(kind RecordKind, data []byte) ([]byte, bool, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, false, fmt.Errorf("failed to parse %s record: %v", kind, err)
+	}
+
+	version := 0
+	if raw, ok := doc[schemaVersionKey]; ok {
+		number, ok := raw.(float64)
+		if !ok || number < 0 || number != float64(int(number)) {
+			return nil, false, fmt.Errorf("invalid schema version %v in %s record", raw, kind)
+		}
+		version = int(number)
+	}
+
+	target := SchemaVersion(kind)
+	if version > target {
+		return nil, false, fmt.Errorf("%s record has schema version %d, newer than supported version %d", kind, version, target)
+	}
+	if version == target {
+		return data, false, nil
+	}
+
+	for version < target {
+		migration := findMigration(kind, version)
+		if migration == nil {
+			return nil, false, fmt.Errorf("no migration for %s records from schema version %d", kind, version)
+		}
+		if err := migration.Apply(doc); err != nil {
+			return nil, false, fmt.Errorf("failed to migrate %s record from schema version %d: %v", kind, version, err)
+		}
+		version++
+		doc[schemaVersionKey] = version
+	}
+
+	migrated, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal migrated %s record: %v", kind, err)
+	}
+	return migrated, true, nil
+}
+
+// MigrateStore upgrades every record in the store to the current schema version.
+// With dryRun set, records are checked but not rewritten.
+func MigrateStore(store Store, dryRun bool) (*MigrationReport, error) {
+	report := &MigrationReport{
+		Checked:  make(map[RecordKind]int),
+		Migrated: make(map[RecordKind]int),
+		Failed:   make(map[RecordKind]int),
+	}
+
+	for _, kind := range AllRecordKinds {
+		records, err := store.List(kind)
+		if err != nil {
+			return report, fmt.Errorf("failed to list %s records: %v", kind, err)
+		}
+
+		for _, record := range records {
+			report.Checked[kind]++
+
+			migrated, changed, err := MigrateRecord(kind, record.Data)
+			if err != nil {
+				report.Failed[kind]++
+				fmt.Printf("❌ %s: %v\n", recordFilename(kind, record.Key), err)
+				continue
+			}
+			if !changed {
+				continue
+			}
+
+			if dryRun {
+				report.Migrated[kind]++
+				fmt.Printf("📝 Would migrate %s to schema version %d\n", recordFilename(kind, record.Key), SchemaVersion(kind))
+				continue
+			}
+
+			record.Data = migrated
+			if err := store.Put(record); err != nil {
+				report.Failed[kind]++
+				fmt.Printf("❌ %s: %v\n", recordFilename(kind, record.Key), err)
+				continue
+			}
+			report.Migrated[kind]++
+			fmt.Printf("🔄 Migrated %s to schema version %d\n", recordFilename(kind, record.Key), SchemaVersion(kind))
+		}
+	}
+
+	return report, nil
+}
+
+// findMigration returns the migration of a kind starting at a version
+func findMigration(kind RecordKind, from int) *Migration {
+	for i := range migrations {
+		if migrations[i].Kind == kind && migrations[i].From == from {
+			return &migrations[i]
+		}
+	}
+	return nil
+}
+
+// renameKey moves a JSON key unless the new key is already present
+func renameKey(doc map[string]interface{}, from, to string) {
+	value, ok := doc[from]
+	if !ok {
+		return
+	}
+	if _, exists := doc[to]; !exists {
+		doc[to] = value
+	}
+	delete(doc, from)
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMigrateRecord(t *testing.T) {
+	tests := []struct {
+		name        string
+		kind        RecordKind
+		data        string
+		want        string // The upgraded document, "" when the data is returned as is
+		wantChanged bool
+		wantErr     bool
+	}{
+		{
+			name:        "version 0 to current",
+			kind:        KindRecipeDetails,
+			data:        `{"recipe_details":{"id":"1"},"metadata":{"recipeID":"1"}}`,
+			want:        `{"schemaVersion":1,"recipeDetails":{"id":"1"},"metadata":{"recipeId":"1"}}`,
+			wantChanged: true,
+		},
+		{
+			name:        "new keys already present win",
+			kind:        KindRecipeDetails,
+			data:        `{"recipe_details":{"id":"old"},"recipeDetails":{"id":"new"}}`,
+			want:        `{"schemaVersion":1,"recipeDetails":{"id":"new"}}`,
+			wantChanged: true,
+		},
+		{
+			name:        "version 0 without changes but the version",
+			kind:        KindRecipes,
+			data:        `{"recipes":[]}`,
+			want:        `{"schemaVersion":1,"recipes":[]}`,
+			wantChanged: true,
+		},
+		{
+			name: "current version",
+			kind: KindLocalRecipe,
+			data: `{"schemaVersion":1,"metadata":{"recipeID":"kept"}}`,
+		},
+		{name: "newer version", kind: KindRecipes, data: `{"schemaVersion":7}`, wantErr: true},
+		{name: "version as a string", kind: KindRecipes, data: `{"schemaVersion":"1"}`, wantErr: true},
+		{name: "negative version", kind: KindRecipes, data: `{"schemaVersion":-1}`, wantErr: true},
+		{name: "fractional version", kind: KindRecipes, data: `{"schemaVersion":0.5}`, wantErr: true},
+		{name: "not JSON", kind: KindRecipes, data: `{"recipes":`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, err := MigrateRecord(tt.kind, []byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MigrateRecord() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+			want := tt.want
+			if want == "" {
+				want = tt.data
+			}
+			assertSameJSON(t, got, want)
+		})
+	}
+}
+
+// assertSameJSON fails the test unless got and want hold the same JSON document
+func assertSameJSON(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var gotDoc, wantDoc interface{}
+	if err := json.Unmarshal(got, &gotDoc); err != nil {
+		t.Fatalf("invalid JSON %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantDoc); err != nil {
+		t.Fatalf("invalid JSON %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(gotDoc, wantDoc) {
+		t.Errorf("document = %s, want %s", got, want)
+	}
+}
+
+func TestMigrateStore(t *testing.T) {
+	store, err := NewJSONStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewJSONStore() = %v", err)
+	}
+	records := []Record{
+		{Kind: KindLocalRecipe, Key: "old", Data: []byte(`{"metadata":{"recipeID":"old"}}`)},
+		{Kind: KindLocalRecipe, Key: "current", Data: []byte(`{"schemaVersion":1,"metadata":{"recipeId":"current"}}`)},
+		{Kind: KindLocalRecipe, Key: "newer", Data: []byte(`{"schemaVersion":9}`)},
+		{Kind: KindRecipeDetails, Key: "old", Data: []byte(`{"recipe_details":{"id":"old"}}`)},
+	}
+	for _, record := range records {
+		if err := store.Put(record); err != nil {
+			t.Fatalf("Put(%s/%s) = %v", record.Kind, record.Key, err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		dryRun       bool
+		wantMigrated map[RecordKind]int
+		wantOld      string // The old local recipe afterwards
+	}{
+		{"dry run", true, map[RecordKind]int{KindLocalRecipe: 1, KindRecipeDetails: 1}, `{"metadata":{"recipeID":"old"}}`},
+		{"migrate", false, map[RecordKind]int{KindLocalRecipe: 1, KindRecipeDetails: 1}, `{"schemaVersion":1,"metadata":{"recipeId":"old"}}`},
+		{"run again", false, map[RecordKind]int{}, `{"schemaVersion":1,"metadata":{"recipeId":"old"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := MigrateStore(store, tt.dryRun)
+			if err != nil {
+				t.Fatalf("MigrateStore() = %v", err)
+			}
+			if want := (map[RecordKind]int{KindLocalRecipe: 3, KindRecipeDetails: 1}); !reflect.DeepEqual(report.Checked, want) {
+				t.Errorf("checked = %v, want %v", report.Checked, want)
+			}
+			if !reflect.DeepEqual(report.Migrated, tt.wantMigrated) {
+				t.Errorf("migrated = %v, want %v", report.Migrated, tt.wantMigrated)
+			}
+			if want := (map[RecordKind]int{KindLocalRecipe: 1}); !reflect.DeepEqual(report.Failed, want) {
+				t.Errorf("failed = %v, want %v", report.Failed, want)
+			}
+
+			record, err := store.Get(KindLocalRecipe, "old")
+			if err != nil {
+				t.Fatalf("Get() = %v", err)
+			}
+			assertSameJSON(t, record.Data, tt.wantOld)
+		})
+	}
+}
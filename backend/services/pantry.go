@@ -0,0 +1,364 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"recipe-finder-backend/models"
+	"recipe-finder-backend/units"
+)
+
+// ErrPantryItemNotFound is returned when a requested pantry item does not exist
+var ErrPantryItemNotFound = errors.New("pantry item not found")
+
+const (
+	// MaxPantrySearchIngredients bounds the pantry items a pantry search sends to
+	// Spoonacular; those closest to expiry are sent first
+	MaxPantrySearchIngredients = 20
+	// ExpiringSoonDays is how close to its expiry date a pantry item is one to use up
+	ExpiringSoonDays = 7
+)
+
+// PantryItemStorage represents the storage structure for a pantry item
+type PantryItemStorage struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	Item          *models.PantryItem `json:"item"`
+}
+
+// PantrySearch is what a "cook from my pantry" search asks for
+type PantrySearch struct {
+	Ingredients []string           // Pantry items to search with, soonest to expire first
+	Urgency     map[string]float64 // How soon items expire by canonical name, 1 for today down to near 0
+	Expiring    []string           // Items expiring within ExpiringSoonDays
+	Expired     []string           // Items left out because they are past their expiry date
+}
+
+// PantryUsage reports what cooking a recipe took from the pantry
+type PantryUsage struct {
+	Used        []PantryUse `json:"used"`
+	NotInPantry []string    `json:"notInPantry"` // Recipe ingredients no pantry item covers
+}
+
+// PantryUse is one recipe ingredient taken from a pantry item. Amount is in the item's
+// unit, and 0 when the item's amount is unknown or can't be compared with the recipe's.
+type PantryUse struct {
+	Ingredient string  `json:"ingredient"`
+	ItemID     string  `json:"itemId"`
+	Item       string  `json:"item"`
+	Amount     float64 `json:"amount"`
+	Unit       string  `json:"unit"`
+	Remaining  float64 `json:"remaining"`
+	UsedUp     bool    `json:"usedUp"` // The item ran out and was removed from the pantry
+}
+
+// NewPantryItemID generates a random UUID (version 4) for a pantry item
+func NewPantryItemID() (string, error) {
+	id, err := newUUID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate pantry item ID: %v", err)
+	}
+	return id, nil
+}
+
+// SavePantryItem creates or replaces a pantry item
+func (s *StorageService) SavePantryItem(item *models.PantryItem) error {
+	s.pantryMu.Lock()
+	defer s.pantryMu.Unlock()
+
+	return s.savePantryItem(item)
+}
+
+// savePantryItem writes a pantry item; callers must hold pantryMu
+func (s *StorageService) savePantryItem(item *models.PantryItem) error {
+	if !uuidPattern.MatchString(item.ID) {
+		return fmt.Errorf("invalid pantry item ID: %q", item.ID)
+	}
+
+	storage := PantryItemStorage{
+		SchemaVersion: SchemaVersion(KindPantryItem),
+		Item:          item,
+	}
+	if err := s.putJSON(KindPantryItem, item.ID, storage); err != nil {
+		return fmt.Errorf("failed to write pantry item: %v", err)
+	}
+
+	fmt.Printf("💾 Saved pantry item %s (%s)\n", item.ID, item.Name)
+	return nil
+}
+
+// LoadPantryItem loads a pantry item, returning ErrPantryItemNotFound if it does not exist
+func (s *StorageService) LoadPantryItem(id string) (*models.PantryItem, error) {
+	if !uuidPattern.MatchString(id) {
+		return nil, ErrPantryItemNotFound
+	}
+
+	var storage PantryItemStorage
+	if err := s.getJSON(KindPantryItem, id, &storage); err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return nil, ErrPantryItemNotFound
+		}
+		return nil, fmt.Errorf("failed to read pantry item: %v", err)
+	}
+	if storage.Item == nil {
+		return nil, fmt.Errorf("pantry item %s has no item", id)
+	}
+
+	return storage.Item, nil
+}
+
+// UpdatePantryItem loads a pantry item, lets update change it and saves it, returning
+// ErrPantryItemNotFound if it does not exist. The pantry stays locked throughout, so the
+// change can't interleave with another, like cooking a recipe.
+func (s *StorageService) UpdatePantryItem(id string, update func(item *models.PantryItem)) (*models.PantryItem, error) {
+	s.pantryMu.Lock()
+	defer s.pantryMu.Unlock()
+
+	item, err := s.LoadPantryItem(id)
+	if err != nil {
+		return nil, err
+	}
+	update(item)
+	item.ID = id
+	if err := s.savePantryItem(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// ListPantryItems returns every pantry item, soonest to expire first; items without an
+// expiry date come last, by name
+func (s *StorageService) ListPantryItems() ([]*models.PantryItem, error) {
+	records, err := s.store.List(KindPantryItem)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*models.PantryItem, 0, len(records))
+	for _, record := range records {
+		var storage PantryItemStorage
+		if err := s.decodeRecord(record, &storage); err != nil || storage.Item == nil {
+			continue // Skip records we can't parse
+		}
+		items = append(items, storage.Item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, aExpires := items[i].Expiry()
+		b, bExpires := items[j].Expiry()
+		if aExpires != bExpires {
+			return aExpires
+		}
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return items[i].Name < items[j].Name
+	})
+	return items, nil
+}
+
+// DeletePantryItem removes a pantry item, returning ErrPantryItemNotFound if it does not exist
+func (s *StorageService) DeletePantryItem(id string) error {
+	s.pantryMu.Lock()
+	defer s.pantryMu.Unlock()
+
+	return s.deletePantryItem(id)
+}
+
+// deletePantryItem removes a pantry item; callers must hold pantryMu
+func (s *StorageService) deletePantryItem(id string) error {
+	if !uuidPattern.MatchString(id) {
+		return ErrPantryItemNotFound
+	}
+
+	if err := s.store.Delete(KindPantryItem, id); err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return ErrPantryItemNotFound
+		}
+		return fmt.Errorf("failed to delete pantry item: %v", err)
+	}
+
+	fmt.Printf("🗑️  Deleted pantry item %s\n", id)
+	return nil
+}
+
+// PlanPantrySearch picks the pantry items to search recipes with on the day now falls
+// on. Items past their expiry date are left out; the rest are sent soonest to expire
+// first, up to MaxPantrySearchIngredients, and those expiring within ExpiringSoonDays
+// are given an urgency that ranks recipes using them first. items must be sorted as
+// ListPantryItems sorts them.
+func PlanPantrySearch(items []*models.PantryItem, now time.Time) PantrySearch {
+	search := PantrySearch{
+		Ingredients: []string{},
+		Urgency:     make(map[string]float64),
+		Expiring:    []string{},
+		Expired:     []string{},
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	seen := make(map[string]bool)
+	for _, item := range items {
+		name := normalizeIngredientName(item.Name)
+		if name == "" {
+			continue
+		}
+
+		if pantryItemExpired(item, now) {
+			search.Expired = append(search.Expired, item.Name)
+			continue
+		}
+		if expiry, ok := item.Expiry(); ok {
+			if days := int(math.Round(expiry.Sub(today).Hours() / 24)); days <= ExpiringSoonDays {
+				// Today is 1; the last day of the window is 1/(ExpiringSoonDays+1)
+				urgency := float64(ExpiringSoonDays+1-days) / float64(ExpiringSoonDays+1)
+				if urgency > search.Urgency[name] {
+					search.Urgency[name] = urgency
+				}
+				search.Expiring = append(search.Expiring, item.Name)
+			}
+		}
+
+		if !seen[name] && len(search.Ingredients) < MaxPantrySearchIngredients {
+			seen[name] = true
+			search.Ingredients = append(search.Ingredients, item.Name)
+		}
+	}
+	return search
+}
+
+// UsePantryIngredients takes a recipe's ingredients, already scaled to the servings
+// cooked, out of the pantry. Each ingredient is taken from the first pantry item that
+// covers it (the same, another name for it or a more specific kind) in
+// ListPantryItems order, so what expires first is used first; items past their expiry
+// date are used only when nothing else covers the ingredient. Amounts are converted
+// to the item's unit, through the ingredient's density between volumes and masses;
+// items that run out are removed. Items whose amount is unknown, or in a unit that
+// can't be compared with the recipe's, are reported as used but left as they are.
+func (s *StorageService) UsePantryIngredients(details *RecipeDetails) (*PantryUsage, error) {
+	s.pantryMu.Lock()
+	defer s.pantryMu.Unlock()
+
+	items, err := s.ListPantryItems()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pantry items: %v", err)
+	}
+
+	now := time.Now()
+	usage := &PantryUsage{Used: []PantryUse{}, NotInPantry: []string{}}
+	changed := make(map[string]bool)
+	usedUp := make(map[string]bool)
+	for _, ingredient := range details.Ingredients {
+		if ingredient.Name == "" {
+			continue
+		}
+
+		// Items past their expiry date are only used if nothing else covers the ingredient
+		var item *models.PantryItem
+		for _, candidate := range items {
+			if usedUp[candidate.ID] || !coversItem(candidate.Name, ingredient.Name) {
+				continue
+			}
+			if item == nil || pantryItemExpired(item, now) && !pantryItemExpired(candidate, now) {
+				item = candidate
+			}
+		}
+		if item == nil {
+			usage.NotInPantry = append(usage.NotInPantry, ingredient.Name)
+			continue
+		}
+
+		use := PantryUse{Ingredient: ingredient.Name, ItemID: item.ID, Item: item.Name, Unit: item.Unit}
+		if taken, ok := amountInItemUnit(ingredient, item); ok && item.Amount > 0 {
+			use.Amount = math.Round(math.Min(taken, item.Amount)*100) / 100
+			item.Amount = math.Round(remaining(item.Amount, taken)*100) / 100
+			changed[item.ID] = true
+			if item.Amount == 0 {
+				usedUp[item.ID] = true
+				use.UsedUp = true
+			}
+		}
+		use.Remaining = item.Amount
+		usage.Used = append(usage.Used, use)
+	}
+
+	for _, item := range items {
+		if !changed[item.ID] {
+			continue
+		}
+		if usedUp[item.ID] {
+			if err := s.deletePantryItem(item.ID); err != nil && !errors.Is(err, ErrPantryItemNotFound) {
+				return usage, err
+			}
+			continue
+		}
+		item.UpdatedAt = now
+		if err := s.savePantryItem(item); err != nil {
+			return usage, err
+		}
+	}
+
+	fmt.Printf("🍳 Cooked %s: used %d pantry items, %d ingredients not in the pantry\n",
+		details.Title, len(changed), len(usage.NotInPantry))
+	return usage, nil
+}
+
+// pantryItemExpired reports whether an item is past its expiry date on the day now falls on
+func pantryItemExpired(item *models.PantryItem, now time.Time) bool {
+	expiry, ok := item.Expiry()
+	return ok && expiry.Before(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+}
+
+// amountInItemUnit converts the amount of an ingredient a recipe needs to a pantry
+// item's unit. Ranges count at their upper bound. It returns false for ingredients
+// without an amount and units that can't be compared: cloves against grams, say, or
+// cups against grams of an ingredient of unknown density. Plain counts and egg sizes
+// count against each other.
+func amountInItemUnit(ingredient DetailedIngredient, item *models.PantryItem) (float64, bool) {
+	amount := math.Max(ingredient.Amount, ingredient.AmountMax)
+	if amount <= 0 {
+		return 0, false
+	}
+
+	need, needKnown := units.Lookup(ingredient.Unit)
+	have, haveKnown := units.Lookup(item.Unit)
+	switch {
+	case needKnown && haveKnown && need.Dimension != units.Count && need.Dimension == have.Dimension:
+		converted, err := units.Convert(units.Quantity{Amount: amount, Unit: need}, have)
+		return converted.Amount, err == nil
+	case needKnown && haveKnown && need.Dimension == units.Volume && have.Dimension == units.Mass:
+		density, ok := units.DensityOf(item.Name)
+		if !ok {
+			return 0, false
+		}
+		ml, _ := units.Convert(units.Quantity{Amount: amount, Unit: need}, units.Milliliter)
+		g, _ := units.Convert(units.Quantity{Amount: ml.Amount * density.GramsPerMilliliter, Unit: units.Gram}, have)
+		return g.Amount, true
+	case needKnown && haveKnown && need.Dimension == units.Mass && have.Dimension == units.Volume:
+		density, ok := units.DensityOf(item.Name)
+		if !ok {
+			return 0, false
+		}
+		g, _ := units.Convert(units.Quantity{Amount: amount, Unit: need}, units.Gram)
+		ml, _ := units.Convert(units.Quantity{Amount: g.Amount / density.GramsPerMilliliter, Unit: units.Milliliter}, have)
+		return ml.Amount, true
+	case isPlainCount(ingredient.Unit) && isPlainCount(item.Unit):
+		return amount, true
+	case needKnown && haveKnown && need == have:
+		return amount, true
+	}
+	return 0, false
+}
+
+// isPlainCount reports whether a unit counts whole things: no unit, or an egg size
+func isPlainCount(unit string) bool {
+	if unit == "" {
+		return true
+	}
+	switch known, _ := units.Lookup(unit); known.Symbol {
+	case "large", "medium", "small":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"recipe-finder-backend/models"
+)
+
+func TestPlanPantrySearch(t *testing.T) {
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	items := []*models.PantryItem{
+		{Name: "milk", ExpiresOn: "2026-10-15"},
+		{Name: "spinach", ExpiresOn: "2026-10-16"},
+		{Name: "Chicken", ExpiresOn: "2026-10-20"},
+		{Name: "yogurt", ExpiresOn: "2026-10-30"},
+		{Name: "rice"},
+		{Name: "Rice"},
+		{Name: "  "},
+	}
+
+	got := PlanPantrySearch(items, now)
+	want := PantrySearch{
+		Ingredients: []string{"spinach", "Chicken", "yogurt", "rice"},
+		Urgency:     map[string]float64{"spinach": 1, "chicken": 0.5},
+		Expiring:    []string{"spinach", "Chicken"},
+		Expired:     []string{"milk"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PlanPantrySearch() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPlanPantrySearchLimit(t *testing.T) {
+	var items []*models.PantryItem
+	for i := 0; i < MaxPantrySearchIngredients+5; i++ {
+		items = append(items, &models.PantryItem{Name: fmt.Sprintf("spice %d", i)})
+	}
+	got := PlanPantrySearch(items, time.Now())
+	if len(got.Ingredients) != MaxPantrySearchIngredients {
+		t.Errorf("%d ingredients, want %d", len(got.Ingredients), MaxPantrySearchIngredients)
+	}
+	if got.Ingredients[0] != "spice 0" {
+		t.Errorf("first ingredient = %q, want the first item", got.Ingredients[0])
+	}
+}
+
+func TestAmountInItemUnit(t *testing.T) {
+	tests := []struct {
+		name       string
+		ingredient DetailedIngredient
+		item       models.PantryItem
+		want       float64
+		wantOK     bool
+	}{
+		{"same dimension", DetailedIngredient{Name: "milk", Amount: 1, Unit: "cup"}, models.PantryItem{Name: "milk", Unit: "ml"}, 236.59, true},
+		{"volume to mass", DetailedIngredient{Name: "butter", Amount: 2, Unit: "tbsp"}, models.PantryItem{Name: "butter", Unit: "g"}, 28.39, true},
+		{"mass to volume", DetailedIngredient{Name: "flour", Amount: 100, Unit: "g"}, models.PantryItem{Name: "flour", Unit: "cups"}, 0.8, true},
+		{"unknown density", DetailedIngredient{Name: "chicken", Amount: 1, Unit: "cup"}, models.PantryItem{Name: "chicken", Unit: "g"}, 0, false},
+		{"egg sizes and counts", DetailedIngredient{Name: "eggs", Amount: 2, Unit: "large"}, models.PantryItem{Name: "eggs"}, 2, true},
+		{"range at its upper bound", DetailedIngredient{Name: "tomatoes", Amount: 2, AmountMax: 3, Unit: "cans"}, models.PantryItem{Name: "tomatoes", Unit: "can"}, 3, true},
+		{"incomparable units", DetailedIngredient{Name: "garlic", Amount: 3, Unit: "cloves"}, models.PantryItem{Name: "garlic", Unit: "g"}, 0, false},
+		{"no amount", DetailedIngredient{Name: "salt"}, models.PantryItem{Name: "salt", Unit: "g"}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := amountInItemUnit(tt.ingredient, &tt.item)
+			if ok != tt.wantOK || math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("amountInItemUnit() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestUsePantryIngredients(t *testing.T) {
+	storage := newTestStorage(t)
+	today := time.Now()
+	items := []*models.PantryItem{
+		{ID: "old-chicken", Name: "chicken breast", Amount: 300, Unit: "g", ExpiresOn: today.AddDate(0, 0, -1).Format(models.DateLayout)},
+		{ID: "chicken", Name: "chicken breast", Amount: 500, Unit: "g", ExpiresOn: today.AddDate(0, 0, 2).Format(models.DateLayout)},
+		{ID: "rice", Name: "rice", Amount: 2, Unit: "cups"},
+		{ID: "eggs", Name: "eggs", Amount: 2},
+		{ID: "milk", Name: "milk"},
+	}
+	labels := make(map[string]string) // Item IDs to the labels used below
+	for _, item := range items {
+		id, err := NewPantryItemID()
+		if err != nil {
+			t.Fatalf("NewPantryItemID() = %v", err)
+		}
+		labels[id], item.ID = item.ID, id
+		if err := storage.SavePantryItem(item); err != nil {
+			t.Fatalf("SavePantryItem(%s) = %v", labels[id], err)
+		}
+	}
+
+	details := &RecipeDetails{Title: "Chicken Rice Bowl", Ingredients: []DetailedIngredient{
+		{Name: "chicken", Amount: 200, Unit: "g"},
+		{Name: "rice", Amount: 1, Unit: "cup"},
+		{Name: "eggs", Amount: 2, Unit: "large"},
+		{Name: "milk", Amount: 0.5, Unit: "cup"},
+		{Name: "basil"},
+	}}
+	usage, err := storage.UsePantryIngredients(details)
+	if err != nil {
+		t.Fatalf("UsePantryIngredients() = %v", err)
+	}
+
+	wantUsed := []PantryUse{
+		{Ingredient: "chicken", ItemID: "chicken", Item: "chicken breast", Amount: 200, Unit: "g", Remaining: 300},
+		{Ingredient: "rice", ItemID: "rice", Item: "rice", Amount: 1, Unit: "cups", Remaining: 1},
+		{Ingredient: "eggs", ItemID: "eggs", Item: "eggs", Amount: 2, Remaining: 0, UsedUp: true},
+		{Ingredient: "milk", ItemID: "milk", Item: "milk"},
+	}
+	for i := range usage.Used {
+		usage.Used[i].ItemID = labels[usage.Used[i].ItemID]
+	}
+	if !reflect.DeepEqual(usage.Used, wantUsed) {
+		t.Errorf("used = %+v, want %+v", usage.Used, wantUsed)
+	}
+	if want := []string{"basil"}; !reflect.DeepEqual(usage.NotInPantry, want) {
+		t.Errorf("not in pantry = %q, want %q", usage.NotInPantry, want)
+	}
+
+	left, err := storage.ListPantryItems()
+	if err != nil {
+		t.Fatalf("ListPantryItems() = %v", err)
+	}
+	amounts := make(map[string]float64)
+	for _, item := range left {
+		amounts[labels[item.ID]] = item.Amount
+	}
+	wantAmounts := map[string]float64{"old-chicken": 300, "chicken": 300, "rice": 1, "milk": 0}
+	if !reflect.DeepEqual(amounts, wantAmounts) {
+		t.Errorf("pantry amounts = %v, want %v", amounts, wantAmounts)
+	}
+}
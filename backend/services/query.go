@@ -0,0 +1,188 @@
+package services
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"recipe-finder-backend/units"
+)
+
+// Spoonacular's findByIngredients ranking modes
+const (
+	// RankMaximizeUsed prefers recipes that use as many of the ingredients as possible
+	RankMaximizeUsed = 1
+	// RankMinimizeMissing prefers recipes that need as few other ingredients as possible
+	RankMinimizeMissing = 2
+)
+
+// DefaultPoolSize is how many recipes are requested from Spoonacular per search
+const DefaultPoolSize = 12
+
+// IngredientQuery is the canonical form of a by-ingredients search: ingredients are
+// lowercased, singularized, deduplicated and sorted, so "Tomatoes, basil" and
+// "basil,tomato" are the same query. It is used for cache keys, storage keys, the
+// Spoonacular request and the ingredients echoed back to clients. It also carries the
+// ranking mode, how many results to request and whether pantry staples count, which
+// are part of its keys.
+type IngredientQuery struct {
+	ingredients []string
+	original    string
+	ranking     int
+	poolSize    int
+	staples     bool
+}
+
+// NewIngredientQuery canonicalizes a list of ingredients; entries may themselves be
+// comma-separated
+func NewIngredientQuery(ingredients []string) IngredientQuery {
+	seen := make(map[string]bool)
+	canonical := make([]string, 0, len(ingredients))
+	for _, entry := range ingredients {
+		for _, ingredient := range strings.Split(entry, ",") {
+			normalized := normalizeIngredientName(ingredient)
+			if normalized == "" || seen[normalized] {
+				continue
+			}
+			seen[normalized] = true
+			canonical = append(canonical, normalized)
+		}
+	}
+	sort.Strings(canonical)
+
+	return IngredientQuery{
+		ingredients: canonical,
+		original:    strings.Join(ingredients, ","),
+		ranking:     RankMaximizeUsed,
+		poolSize:    DefaultPoolSize,
+	}
+}
+
+// WithPool returns the query with another ranking mode and number of results to request
+func (q IngredientQuery) WithPool(ranking, poolSize int) IngredientQuery {
+	q.ranking = ranking
+	q.poolSize = poolSize
+	return q
+}
+
+// WithStaples returns the query with pantry staples like water, salt and flour counted
+// as ingredients. Spoonacular ignores them by default, assuming everyone has them.
+func (q IngredientQuery) WithStaples(staples bool) IngredientQuery {
+	q.staples = staples
+	return q
+}
+
+// IncludesStaples reports whether pantry staples count as ingredients
+func (q IngredientQuery) IncludesStaples() bool {
+	return q.staples
+}
+
+// Ranking returns the Spoonacular ranking mode
+func (q IngredientQuery) Ranking() int {
+	return q.ranking
+}
+
+// PoolSize returns how many results to request from Spoonacular
+func (q IngredientQuery) PoolSize() int {
+	return q.poolSize
+}
+
+// poolSuffix distinguishes keys of non-default pools; the default pool keeps the keys it
+// had before pools were configurable so stored results stay valid
+func (q IngredientQuery) poolSuffix() string {
+	suffix := ""
+	if q.ranking != RankMaximizeUsed && !q.IsEmpty() {
+		suffix += fmt.Sprintf("_r%d", q.ranking)
+	}
+	if q.poolSize != DefaultPoolSize {
+		suffix += fmt.Sprintf("_n%d", q.poolSize)
+	}
+	if q.staples && !q.IsEmpty() {
+		suffix += "_s"
+	}
+	return suffix
+}
+
+// ParseIngredientQuery canonicalizes a comma-separated list of ingredients
+func ParseIngredientQuery(query string) IngredientQuery {
+	return NewIngredientQuery([]string{query})
+}
+
+// Ingredients returns the canonical ingredients
+func (q IngredientQuery) Ingredients() []string {
+	return append([]string{}, q.ingredients...)
+}
+
+// IsEmpty reports whether the query has no ingredients, which means popular recipes
+func (q IngredientQuery) IsEmpty() bool {
+	return len(q.ingredients) == 0
+}
+
+// String returns the canonical ingredients joined by commas
+func (q IngredientQuery) String() string {
+	return strings.Join(q.ingredients, ",")
+}
+
+// Original returns the ingredients as the caller gave them
+func (q IngredientQuery) Original() string {
+	return q.original
+}
+
+// CacheKey returns the in-memory cache and request coalescing key
+func (q IngredientQuery) CacheKey() string {
+	if q.IsEmpty() {
+		return popularRecipesKey + q.poolSuffix()
+	}
+	return "search_" + q.String() + q.poolSuffix()
+}
+
+// StorageKey returns the record key of the query's stored results: a short hash of
+// the canonical form, or "popular" for the empty query, plus the pool suffix
+func (q IngredientQuery) StorageKey() string {
+	if q.IsEmpty() {
+		return popularRecipesKey + q.poolSuffix()
+	}
+
+	hasher := md5.New()
+	hasher.Write([]byte(q.String()))
+	hash := hex.EncodeToString(hasher.Sum(nil))
+
+	// Use first 12 characters of hash for shorter keys
+	return hash[:12] + q.poolSuffix()
+}
+
+// LegacyStorageKey returns the record key the query's results were stored under before
+// queries were canonicalized: a hash of the ingredients as given, only trimmed,
+// lowercased and sorted, so "tomatoes" and "tomato" had different keys. ok is false
+// for the empty query and when the old key is the current one.
+func (q IngredientQuery) LegacyStorageKey() (key string, ok bool) {
+	if q.IsEmpty() {
+		return "", false
+	}
+
+	var ingredients []string
+	for _, ingredient := range strings.Split(q.original, ",") {
+		if trimmed := strings.TrimSpace(strings.ToLower(ingredient)); trimmed != "" {
+			ingredients = append(ingredients, trimmed)
+		}
+	}
+	sort.Strings(ingredients)
+
+	hasher := md5.New()
+	hasher.Write([]byte(strings.Join(ingredients, ",")))
+	key = hex.EncodeToString(hasher.Sum(nil))[:12]
+	return key, key != q.StorageKey()
+}
+
+// normalizeIngredientName lowercases an ingredient, collapses whitespace and
+// singularizes its last word ("Cherry  Tomatoes" -> "cherry tomato")
+func normalizeIngredientName(name string) string {
+	words := strings.Fields(strings.ToLower(name))
+	if len(words) == 0 {
+		return ""
+	}
+	words[len(words)-1] = units.Singular(words[len(words)-1])
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewIngredientQuery(t *testing.T) {
+	tests := []struct {
+		ingredients []string
+		want        []string
+	}{
+		{[]string{"Tomatoes", " basil"}, []string{"basil", "tomato"}},
+		{[]string{"basil,tomato"}, []string{"basil", "tomato"}},
+		{[]string{"Cherry  Tomatoes", "cherry tomato"}, []string{"cherry tomato"}},
+		{[]string{"berries, leaves, hummus, swiss"}, []string{"berry", "hummus", "leaf", "swiss"}},
+		{[]string{" , ", ""}, []string{}},
+		{nil, []string{}},
+	}
+	for _, tt := range tests {
+		query := NewIngredientQuery(tt.ingredients)
+		if got := query.Ingredients(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("NewIngredientQuery(%q) = %q, want %q", tt.ingredients, got, tt.want)
+		}
+		if query.IsEmpty() != (len(tt.want) == 0) {
+			t.Errorf("NewIngredientQuery(%q).IsEmpty() = %v", tt.ingredients, query.IsEmpty())
+		}
+	}
+}
+
+func TestIngredientQueryKeys(t *testing.T) {
+	a := NewIngredientQuery([]string{"Tomatoes", "basil"})
+	b := ParseIngredientQuery("basil, tomato")
+	c := ParseIngredientQuery("basil")
+
+	if a.CacheKey() != b.CacheKey() || a.StorageKey() != b.StorageKey() {
+		t.Errorf("equivalent queries have keys %q/%q and %q/%q", a.CacheKey(), a.StorageKey(), b.CacheKey(), b.StorageKey())
+	}
+	if a.StorageKey() == c.StorageKey() {
+		t.Errorf("different queries share the storage key %q", a.StorageKey())
+	}
+	if got := a.CacheKey(); got != "search_basil,tomato" {
+		t.Errorf("CacheKey() = %q, want %q", got, "search_basil,tomato")
+	}
+	if got := len(a.StorageKey()); got != 12 {
+		t.Errorf("StorageKey() has %d characters, want 12", got)
+	}
+
+	empty := ParseIngredientQuery("")
+	if empty.CacheKey() != popularRecipesKey || empty.StorageKey() != popularRecipesKey {
+		t.Errorf("empty query keys = %q, %q, want %q", empty.CacheKey(), empty.StorageKey(), popularRecipesKey)
+	}
+	if got := a.Original(); got != "Tomatoes,basil" {
+		t.Errorf("Original() = %q, want %q", got, "Tomatoes,basil")
+	}
+}
+
+func TestIngredientQueryLegacyStorageKey(t *testing.T) {
+	// The old key only lowercased, trimmed and sorted, so it matches for any spelling
+	// with the same words
+	plural, ok := ParseIngredientQuery("Tomatoes, Basil").LegacyStorageKey()
+	if !ok {
+		t.Fatal("LegacyStorageKey() of a plural query is not ok")
+	}
+	if again, _ := ParseIngredientQuery("tomatoes,basil ").LegacyStorageKey(); again != plural {
+		t.Errorf("LegacyStorageKey() = %q and %q for the same words", plural, again)
+	}
+	if singular, _ := ParseIngredientQuery("tomato,basil").LegacyStorageKey(); singular == plural {
+		t.Errorf("LegacyStorageKey() = %q for both plural and singular words", plural)
+	}
+
+	// When nothing was canonicalized away the old key is the current one
+	if key, ok := ParseIngredientQuery("basil,tomato").LegacyStorageKey(); ok {
+		t.Errorf("LegacyStorageKey() of a canonical query = %q, true; want false", key)
+	}
+	if key, ok := ParseIngredientQuery("").LegacyStorageKey(); ok {
+		t.Errorf("LegacyStorageKey() of the empty query = %q, true; want false", key)
+	}
+}
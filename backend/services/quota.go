@@ -0,0 +1,277 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExhausted is returned instead of calling Spoonacular once the daily point
+// budget is spent; callers fall back to cached data
+var ErrQuotaExhausted = errors.New("daily Spoonacular point budget reached")
+
+// Spoonacular reports point usage in these response headers
+const (
+	quotaRequestHeader = "X-API-Quota-Request"
+	quotaUsedHeader    = "X-API-Quota-Used"
+	quotaLeftHeader    = "X-API-Quota-Left"
+)
+
+// blockedSaveInterval is how often refused calls alone cause the usage to be written;
+// the blocked count otherwise goes out with the next recorded response
+const blockedSaveInterval = time.Minute
+
+// QuotaReporter is implemented by recipe providers that track upstream quota usage
+type QuotaReporter interface {
+	QuotaStatus() QuotaStatus
+}
+
+// EndpointUsage counts the calls and points spent on one Spoonacular endpoint
+type EndpointUsage struct {
+	Requests int     `json:"requests"`
+	Points   float64 `json:"points"`
+	Failures int     `json:"failures"`
+}
+
+// QuotaUsage is the point usage of one UTC day, persisted as a quota record keyed by date
+type QuotaUsage struct {
+	Date        string                    `json:"date"`
+	PointsUsed  float64                   `json:"pointsUsed"`
+	QuotaUsed   *float64                  `json:"quotaUsed,omitempty"` // Last X-API-Quota-Used reported by Spoonacular
+	QuotaLeft   *float64                  `json:"quotaLeft,omitempty"` // Last X-API-Quota-Left reported by Spoonacular
+	Requests    int                       `json:"requests"`
+	Blocked     int                       `json:"blocked"` // Calls skipped because the budget was spent
+	Endpoints   map[string]*EndpointUsage `json:"endpoints"`
+	ExhaustedAt *time.Time                `json:"exhaustedAt,omitempty"`
+	LastUpdated time.Time                 `json:"lastUpdated"`
+}
+
+// QuotaUsageStorage represents the storage structure for a day's quota usage
+type QuotaUsageStorage struct {
+	SchemaVersion int `json:"schemaVersion"`
+	QuotaUsage
+}
+
+// QuotaStatus is the quota report served by the admin endpoint
+type QuotaStatus struct {
+	QuotaUsage
+	Budget    float64   `json:"budget"` // 0 means no budget is enforced
+	CacheOnly bool      `json:"cacheOnly"`
+	ResetsAt  time.Time `json:"resetsAt"`
+}
+
+// getDailyPointBudget returns the daily point budget from environment variables (0 = unlimited)
+func getDailyPointBudget() float64 {
+	if budget := os.Getenv("SPOONACULAR_DAILY_POINT_BUDGET"); budget != "" {
+		if b, err := strconv.ParseFloat(budget, 64); err == nil && b > 0 {
+			return b
+		}
+	}
+	return 0
+}
+
+// QuotaTracker records the points spent on Spoonacular per UTC day and decides whether
+// another call fits in the daily budget. Usage is written to storage after every call
+// so it survives restarts.
+type QuotaTracker struct {
+	mu      sync.Mutex
+	storage *StorageService
+	budget  float64
+	usage   QuotaUsage
+	savedAt time.Time
+	now     func() time.Time
+}
+
+// NewQuotaTracker creates a tracker persisting to storage and enforcing budget points per
+// day (0 disables enforcement), resuming today's usage if it was recorded before
+func NewQuotaTracker(storage *StorageService, budget float64) *QuotaTracker {
+	t := &QuotaTracker{
+		storage: storage,
+		budget:  budget,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+	t.usage = t.loadDay(t.today())
+	return t
+}
+
+// today returns the current UTC date, the unit Spoonacular resets quotas on
+func (t *QuotaTracker) today() string {
+	return t.now().Format("2006-01-02")
+}
+
+// loadDay reads the stored usage of a day, or starts an empty one
+func (t *QuotaTracker) loadDay(date string) QuotaUsage {
+	usage := QuotaUsage{Date: date, Endpoints: make(map[string]*EndpointUsage)}
+	if t.storage == nil {
+		return usage
+	}
+
+	var storage QuotaUsageStorage
+	if err := t.storage.getJSON(KindQuota, date, &storage); err != nil {
+		if !errors.Is(err, ErrRecordNotFound) {
+			fmt.Printf("⚠️  Warning: Could not load quota usage for %s: %v\n", date, err)
+		}
+		return usage
+	}
+	usage = storage.QuotaUsage
+	usage.Date = date
+	if usage.Endpoints == nil {
+		usage.Endpoints = make(map[string]*EndpointUsage)
+	}
+	return usage
+}
+
+// rollover starts a new day's usage after UTC midnight; callers must hold the lock
+func (t *QuotaTracker) rollover() {
+	if today := t.today(); t.usage.Date != today {
+		fmt.Printf("🔁 Spoonacular quota reset for %s\n", today)
+		t.usage = t.loadDay(today)
+	}
+}
+
+// exhausted reports whether today's budget is spent; callers must hold the lock
+func (t *QuotaTracker) exhausted() bool {
+	if t.usage.ExhaustedAt != nil {
+		return true
+	}
+	if t.usage.QuotaLeft != nil && *t.usage.QuotaLeft <= 0 {
+		return true
+	}
+	if t.budget <= 0 {
+		return false
+	}
+	used := t.usage.PointsUsed
+	if t.usage.QuotaUsed != nil && *t.usage.QuotaUsed > used {
+		used = *t.usage.QuotaUsed
+	}
+	return used >= t.budget
+}
+
+// Allow returns ErrQuotaExhausted if no more Spoonacular calls should be made today
+func (t *QuotaTracker) Allow(endpoint string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rollover()
+	if !t.exhausted() {
+		return nil
+	}
+
+	// A refusal changes nothing but the blocked count, so it isn't written on every call
+	t.usage.Blocked++
+	if t.now().Sub(t.savedAt) >= blockedSaveInterval {
+		t.save()
+	}
+	fmt.Printf("🪫 Skipping Spoonacular %s call: %v\n", endpoint, ErrQuotaExhausted)
+	return ErrQuotaExhausted
+}
+
+// Record accounts for a Spoonacular response: the points from its quota headers, and
+// whether it reported the quota as spent (402)
+func (t *QuotaTracker) Record(endpoint string, resp *http.Response) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rollover()
+	usage := t.usage.Endpoints[endpoint]
+	if usage == nil {
+		usage = &EndpointUsage{}
+		t.usage.Endpoints[endpoint] = usage
+	}
+
+	points := quotaHeader(resp.Header, quotaRequestHeader)
+	usage.Requests++
+	t.usage.Requests++
+	if points != nil {
+		usage.Points = roundPoints(usage.Points + *points)
+		t.usage.PointsUsed = roundPoints(t.usage.PointsUsed + *points)
+	}
+	if used := quotaHeader(resp.Header, quotaUsedHeader); used != nil {
+		t.usage.QuotaUsed = used
+	}
+	if left := quotaHeader(resp.Header, quotaLeftHeader); left != nil {
+		t.usage.QuotaLeft = left
+	}
+	if resp.StatusCode != http.StatusOK {
+		usage.Failures++
+	}
+
+	wasExhausted := t.usage.ExhaustedAt != nil
+	if resp.StatusCode == http.StatusPaymentRequired || t.exhausted() {
+		if !wasExhausted {
+			now := t.now()
+			t.usage.ExhaustedAt = &now
+			fmt.Printf("🪫 Spoonacular daily quota reached (%.2f points used), serving cached data only until %s\n",
+				t.usage.PointsUsed, t.resetsAt().Format(time.RFC3339))
+		}
+	}
+	t.usage.LastUpdated = t.now()
+	t.save()
+}
+
+// Status returns today's usage and whether the service is in cache-only mode
+func (t *QuotaTracker) Status() QuotaStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rollover()
+	usage := t.usage
+	usage.Endpoints = make(map[string]*EndpointUsage, len(t.usage.Endpoints))
+	for name, endpoint := range t.usage.Endpoints {
+		copied := *endpoint
+		usage.Endpoints[name] = &copied
+	}
+
+	return QuotaStatus{
+		QuotaUsage: usage,
+		Budget:     t.budget,
+		CacheOnly:  t.exhausted(),
+		ResetsAt:   t.resetsAt(),
+	}
+}
+
+// resetsAt returns the next UTC midnight
+func (t *QuotaTracker) resetsAt() time.Time {
+	now := t.now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+}
+
+// save persists today's usage; callers must hold the lock
+func (t *QuotaTracker) save() {
+	if t.storage == nil {
+		return
+	}
+
+	storage := QuotaUsageStorage{
+		SchemaVersion: SchemaVersion(KindQuota),
+		QuotaUsage:    t.usage,
+	}
+	if err := t.storage.putJSON(KindQuota, t.usage.Date, storage); err != nil {
+		fmt.Printf("⚠️  Warning: Could not save quota usage: %v\n", err)
+		return
+	}
+	t.savedAt = t.now()
+}
+
+// roundPoints drops the floating point noise from summing fractional points
+func roundPoints(points float64) float64 {
+	return math.Round(points*10000) / 10000
+}
+
+// quotaHeader parses a numeric quota header, returning nil if it is missing or malformed
+func quotaHeader(header http.Header, name string) *float64 {
+	value := header.Get(name)
+	if value == "" {
+		return nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil
+	}
+	return &number
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newTestQuota returns a tracker whose clock reads *clock, resuming the usage stored
+// for that day
+func newTestQuota(storage *StorageService, budget float64, clock *time.Time) *QuotaTracker {
+	t := NewQuotaTracker(storage, budget)
+	t.now = func() time.Time { return *clock }
+	t.usage = t.loadDay(t.today())
+	return t
+}
+
+// quotaResponse returns a Spoonacular response reporting the points a request cost
+func quotaResponse(status int, points string) *http.Response {
+	header := http.Header{}
+	if points != "" {
+		header.Set(quotaRequestHeader, points)
+	}
+	return &http.Response{StatusCode: status, Header: header}
+}
+
+func TestQuotaTrackerBudget(t *testing.T) {
+	clock := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	quota := newTestQuota(newTestStorage(t), 10, &clock)
+
+	for _, points := range []string{"6", "4.5"} {
+		if err := quota.Allow("findByIngredients"); err != nil {
+			t.Fatalf("Allow() with %.2f points used = %v, want nil", quota.Status().PointsUsed, err)
+		}
+		quota.Record("findByIngredients", quotaResponse(http.StatusOK, points))
+	}
+
+	if err := quota.Allow("findByIngredients"); !errors.Is(err, ErrQuotaExhausted) {
+		t.Errorf("Allow() over budget = %v, want ErrQuotaExhausted", err)
+	}
+	status := quota.Status()
+	if !status.CacheOnly || status.PointsUsed != 10.5 || status.Blocked != 1 || status.ExhaustedAt == nil {
+		t.Errorf("Status() = %+v, want cache only with 10.5 points used and 1 blocked call", status)
+	}
+	if usage := status.Endpoints["findByIngredients"]; usage == nil || usage.Requests != 2 || usage.Points != 10.5 {
+		t.Errorf("endpoint usage = %+v, want 2 requests and 10.5 points", usage)
+	}
+	if want := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC); !status.ResetsAt.Equal(want) {
+		t.Errorf("ResetsAt = %v, want %v", status.ResetsAt, want)
+	}
+}
+
+func TestQuotaTrackerSpoonacularLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header string
+		value  string
+	}{
+		{"payment required", http.StatusPaymentRequired, "", ""},
+		{"nothing left", http.StatusOK, quotaLeftHeader, "0"},
+		{"quota used over budget", http.StatusOK, quotaUsedHeader, "150"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+			quota := newTestQuota(nil, 100, &clock)
+
+			resp := quotaResponse(tt.status, "1")
+			if tt.header != "" {
+				resp.Header.Set(tt.header, tt.value)
+			}
+			quota.Record("information", resp)
+			if err := quota.Allow("information"); !errors.Is(err, ErrQuotaExhausted) {
+				t.Errorf("Allow() = %v, want ErrQuotaExhausted", err)
+			}
+		})
+	}
+}
+
+func TestQuotaTrackerRollover(t *testing.T) {
+	clock := time.Date(2026, 10, 16, 23, 59, 0, 0, time.UTC)
+	quota := newTestQuota(newTestStorage(t), 5, &clock)
+	quota.Record("random", quotaResponse(http.StatusOK, "5"))
+	if err := quota.Allow("random"); !errors.Is(err, ErrQuotaExhausted) {
+		t.Fatalf("Allow() over budget = %v, want ErrQuotaExhausted", err)
+	}
+
+	// A new UTC day starts with a fresh budget
+	clock = clock.Add(2 * time.Minute)
+	if err := quota.Allow("random"); err != nil {
+		t.Errorf("Allow() after midnight = %v, want nil", err)
+	}
+	status := quota.Status()
+	if status.Date != "2026-10-17" || status.PointsUsed != 0 || status.CacheOnly {
+		t.Errorf("Status() after midnight = %+v, want an empty 2026-10-17", status)
+	}
+}
+
+func TestQuotaTrackerPersistence(t *testing.T) {
+	storage := newTestStorage(t)
+	clock := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	quota := newTestQuota(storage, 10, &clock)
+	quota.Record("findByIngredients", quotaResponse(http.StatusOK, "3.25"))
+	quota.Record("findByIngredients", quotaResponse(http.StatusPaymentRequired, ""))
+
+	// A restart resumes the day's usage, including the exhausted budget
+	restarted := newTestQuota(storage, 10, &clock)
+	status := restarted.Status()
+	if status.PointsUsed != 3.25 || status.Requests != 2 || !status.CacheOnly {
+		t.Errorf("Status() after a restart = %+v, want 3.25 points over 2 requests, cache only", status)
+	}
+
+	// The next day doesn't inherit it
+	clock = clock.AddDate(0, 0, 1)
+	if status := newTestQuota(storage, 10, &clock).Status(); status.PointsUsed != 0 || status.CacheOnly {
+		t.Errorf("Status() the next day = %+v, want no usage", status)
+	}
+}
+
+func TestQuotaTrackerBlockedSaves(t *testing.T) {
+	storage := newTestStorage(t)
+	clock := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	quota := newTestQuota(storage, 1, &clock)
+	quota.Record("random", quotaResponse(http.StatusOK, "1"))
+
+	storedBlocked := func() int {
+		var stored QuotaUsageStorage
+		if err := storage.getJSON(KindQuota, "2026-10-16", &stored); err != nil {
+			t.Fatalf("reading the quota record: %v", err)
+		}
+		return stored.Blocked
+	}
+
+	// Refusals right after a save are only counted in memory
+	quota.Allow("random")
+	quota.Allow("random")
+	if got := storedBlocked(); got != 0 {
+		t.Errorf("stored blocked = %d right after a save, want 0", got)
+	}
+
+	clock = clock.Add(blockedSaveInterval)
+	quota.Allow("random")
+	if got := storedBlocked(); got != 3 {
+		t.Errorf("stored blocked = %d after %v, want 3", got, blockedSaveInterval)
+	}
+}
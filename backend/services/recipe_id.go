@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidRecipeID is returned for recipe IDs that cannot name any recipe
+var ErrInvalidRecipeID = errors.New("invalid recipe ID")
+
+// RecipeSource is the provider a recipe comes from
+type RecipeSource string
+
+// Recipe sources, also used as ID prefixes
+const (
+	SourceSpoonacular RecipeSource = "spoonacular"
+	SourceLocal       RecipeSource = "local"
+)
+
+// maxSpoonacularID bounds Spoonacular IDs so that they fit the int the API uses
+const maxSpoonacularID = 1<<31 - 1
+
+// RecipeID identifies a recipe and the provider it comes from. It is written as
+// "spoonacular:716429" or "local:<uuid>"; bare numbers are Spoonacular IDs and bare
+// UUIDs are local ones, which is how IDs appear in responses.
+type RecipeID struct {
+	source RecipeSource
+	value  string
+}
+
+// ParseRecipeID validates a recipe ID, returning an error wrapping ErrInvalidRecipeID
+// if it is malformed
+func ParseRecipeID(raw string) (RecipeID, error) {
+	value := strings.TrimSpace(raw)
+	source := RecipeSource("")
+	if prefix, rest, ok := strings.Cut(value, ":"); ok {
+		source, value = RecipeSource(strings.ToLower(prefix)), rest
+	}
+
+	switch source {
+	case "":
+		if id, err := parseSpoonacularID(value); err == nil {
+			return id, nil
+		}
+		if id, err := parseLocalID(value); err == nil {
+			return id, nil
+		}
+		return RecipeID{}, fmt.Errorf("%w: %q is neither a Spoonacular ID nor a local recipe UUID", ErrInvalidRecipeID, raw)
+	case SourceSpoonacular:
+		return parseSpoonacularID(value)
+	case SourceLocal:
+		return parseLocalID(value)
+	default:
+		return RecipeID{}, fmt.Errorf("%w: unknown recipe source %q", ErrInvalidRecipeID, source)
+	}
+}
+
+// parseSpoonacularID accepts a positive decimal number without leading zeros
+func parseSpoonacularID(value string) (RecipeID, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 || n > maxSpoonacularID || strconv.FormatInt(n, 10) != value {
+		return RecipeID{}, fmt.Errorf("%w: Spoonacular recipe IDs are positive numbers, got %q", ErrInvalidRecipeID, value)
+	}
+	return RecipeID{source: SourceSpoonacular, value: value}, nil
+}
+
+// parseLocalID accepts the UUIDs assigned to user-authored recipes, in either case
+func parseLocalID(value string) (RecipeID, error) {
+	value = strings.ToLower(value)
+	if !IsLocalRecipeID(value) {
+		return RecipeID{}, fmt.Errorf("%w: local recipe IDs are UUIDs, got %q", ErrInvalidRecipeID, value)
+	}
+	return RecipeID{source: SourceLocal, value: value}, nil
+}
+
+// Source returns the provider the recipe comes from
+func (id RecipeID) Source() RecipeSource {
+	return id.source
+}
+
+// Value returns the provider's own ID: the Spoonacular number or the local UUID. It is
+// safe to use in URLs and storage keys.
+func (id RecipeID) Value() string {
+	return id.value
+}
+
+// IsLocal reports whether the recipe is user-authored
+func (id RecipeID) IsLocal() bool {
+	return id.source == SourceLocal
+}
+
+// String returns the prefixed form, e.g. "spoonacular:716429"
+func (id RecipeID) String() string {
+	return string(id.source) + ":" + id.value
+}
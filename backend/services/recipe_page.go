@@ -0,0 +1,461 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidSearchOptions is returned for out-of-range paging, sort or ranking options
+var ErrInvalidSearchOptions = errors.New("invalid search options")
+
+// RecipeSort orders a page of recipe search results
+type RecipeSort string
+
+// Sort orders for recipe searches
+const (
+	SortMatch    RecipeSort = "match"    // Most of the user's ingredients used first
+	SortMissing  RecipeSort = "missing"  // Fewest other ingredients needed first
+	SortTime     RecipeSort = "time"     // Quickest to make first
+	SortHealth   RecipeSort = "health"   // Highest health score first
+	SortPrice    RecipeSort = "price"    // Cheapest per serving first
+	SortExpiring RecipeSort = "expiring" // Using up the ingredients closest to expiry first
+)
+
+// RecipeSorts lists the accepted sort orders
+var RecipeSorts = []RecipeSort{SortMatch, SortMissing, SortTime, SortHealth, SortPrice, SortExpiring}
+
+const (
+	// DefaultPageLimit is how many recipes a page holds when no limit is given
+	DefaultPageLimit = 12
+	// MaxPageLimit is the largest page, and also Spoonacular's largest result set
+	MaxPageLimit = 100
+)
+
+// maxSortDetails caps how many recipes' details one sort fetches from Spoonacular. Only
+// the first recipes of the pool missing them are fetched, which bounds the points one
+// informationBulk call costs however large the pool; the rest sort last.
+const maxSortDetails = 24
+
+// poolSizes are the result set sizes requested from Spoonacular. A page is cut from the
+// smallest pool that covers it, so nearby pages share one upstream call and cache entry.
+var poolSizes = []int{DefaultPoolSize, 24, 48, MaxPageLimit}
+
+// RecipeSearchOptions selects a page of recipe search results
+type RecipeSearchOptions struct {
+	Ingredients []string
+	Limit       int                // Page size, DefaultPageLimit if 0
+	Offset      int                // Results to skip
+	Sort        RecipeSort         // SortMatch if empty, or SortMissing when ranking by missing ingredients
+	Ranking     int                // Spoonacular ranking mode, RankMaximizeUsed if 0
+	Staples     bool               // Count pantry staples like salt and flour, which Spoonacular otherwise ignores
+	Urgency     map[string]float64 // Ingredients to use up, by canonical name, and how urgently (0 to 1)
+}
+
+// RecipePage is one page of recipe search results
+type RecipePage struct {
+	Recipes     []Recipe
+	Ingredients []string // The canonical ingredients searched for
+	Total       int      // Results fetched so far, the size of the pool the page was cut from
+	Limit       int
+	Offset      int
+	Sort        RecipeSort
+	Ranking     int
+	HasMore     bool // Further results are in the pool, or a larger pool may hold them
+	Unsorted    int  // Recipes left without the details the sort order needs, sorted last
+	Stale       bool // The results were served from storage past their soft TTL
+}
+
+// normalize fills in defaults and validates the options
+func (o RecipeSearchOptions) normalize() (RecipeSearchOptions, error) {
+	if o.Limit == 0 {
+		o.Limit = DefaultPageLimit
+	}
+	if o.Limit < 1 || o.Limit > MaxPageLimit {
+		return o, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidSearchOptions, MaxPageLimit)
+	}
+	if o.Offset < 0 {
+		return o, fmt.Errorf("%w: offset must not be negative", ErrInvalidSearchOptions)
+	}
+
+	if o.Ranking == 0 {
+		o.Ranking = RankMaximizeUsed
+	}
+	if o.Ranking != RankMaximizeUsed && o.Ranking != RankMinimizeMissing {
+		return o, fmt.Errorf("%w: ranking must be %d (maximize used ingredients) or %d (minimize missing ingredients)",
+			ErrInvalidSearchOptions, RankMaximizeUsed, RankMinimizeMissing)
+	}
+
+	o.Sort = RecipeSort(strings.ToLower(string(o.Sort)))
+	if o.Sort == "" {
+		o.Sort = SortMatch
+		if o.Ranking == RankMinimizeMissing {
+			o.Sort = SortMissing
+		}
+	}
+	for _, known := range RecipeSorts {
+		if o.Sort == known {
+			return o, nil
+		}
+	}
+	return o, fmt.Errorf("%w: sort must be one of %v", ErrInvalidSearchOptions, RecipeSorts)
+}
+
+// poolSizeFor returns the smallest pool covering the first n results
+func poolSizeFor(n int) int {
+	for _, size := range poolSizes {
+		if n <= size {
+			return size
+		}
+	}
+	return poolSizes[len(poolSizes)-1]
+}
+
+// SearchRecipesPageContext searches recipes by ingredients and returns one sorted page.
+// Sorting and paging happen over the pool of results Spoonacular ranked for the query;
+// sorting by time, health or price first fills in those values from stored recipe
+// details, fetching the missing ones with a single informationBulk call.
+func (s *SpoonacularService) SearchRecipesPageContext(ctx context.Context, options RecipeSearchOptions) (*RecipePage, error) {
+	options, err := options.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	poolSize := poolSizeFor(options.Offset + options.Limit)
+	query := NewIngredientQuery(options.Ingredients)
+	query = query.WithPool(options.Ranking, poolSize).WithStaples(options.Staples)
+	pool, err := s.searchPool(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Work on a copy: the pool may be the cached slice
+	ingredients := query.Ingredients()
+	recipes := append([]Recipe(nil), pool...)
+	for i := range recipes {
+		matchRecipe(&recipes[i], ingredients)
+		if len(options.Urgency) > 0 {
+			markExpiring(&recipes[i], options.Urgency)
+		}
+	}
+
+	unsorted := 0
+	switch options.Sort {
+	case SortTime, SortHealth, SortPrice:
+		unsorted = s.enrichRecipes(ctx, recipes)
+	}
+	sortRecipes(recipes, options.Sort)
+
+	page := &RecipePage{
+		Recipes:     []Recipe{},
+		Ingredients: ingredients,
+		Total:       len(recipes),
+		Limit:       options.Limit,
+		Offset:      options.Offset,
+		Sort:        options.Sort,
+		Ranking:     options.Ranking,
+		Unsorted:    unsorted,
+		Stale:       len(recipes) > 0 && recipes[0].Stale,
+	}
+	if options.Offset < len(recipes) {
+		end := options.Offset + options.Limit
+		if end > len(recipes) {
+			end = len(recipes)
+		}
+		page.Recipes = recipes[options.Offset:end]
+		// A full pool says nothing about what lies past it, so a page ending one has
+		// more unless it is the largest pool Spoonacular serves
+		page.HasMore = end < len(recipes) || len(recipes) == poolSize && poolSize < MaxPageLimit
+	}
+	return page, nil
+}
+
+// matchRecipe scores a search result against the user's ingredients and makes sure it
+// says which ingredients the user has, which they still need and which of theirs go
+// unused. Spoonacular's breakdown is kept, with missed ingredients that the matcher
+// finds the user has (a synonym or a more specific kind, say) moved to used. Results
+// stored before the breakdown was kept get one from the matcher alone, without amounts.
+// MatchCount never drops below Spoonacular's own count.
+func matchRecipe(recipe *Recipe, userIngredients []string) {
+	if len(userIngredients) > 0 {
+		match := MatchIngredients(userIngredients, recipe.Ingredients)
+
+		if recipe.UsedIngredients == nil && recipe.MissedIngredients == nil {
+			recipe.UsedIngredients = []DetailedIngredient{}
+			recipe.MissedIngredients = []DetailedIngredient{}
+			for _, ingredientMatch := range match.Ingredients {
+				ingredient := DetailedIngredient{Name: ingredientMatch.Ingredient, Original: ingredientMatch.Ingredient}
+				if ingredientMatch.Matched() {
+					recipe.UsedIngredients = append(recipe.UsedIngredients, ingredient)
+				} else {
+					recipe.MissedIngredients = append(recipe.MissedIngredients, ingredient)
+				}
+			}
+			recipe.UnusedIngredients = match.Unused
+		} else {
+			reconcileMatch(recipe, &match)
+		}
+
+		recipe.Matches = match.Ingredients
+		recipe.MatchScore = match.Score
+		if match.MatchCount > recipe.MatchCount {
+			recipe.MatchCount = match.MatchCount
+		}
+	}
+
+	// Respond with empty lists rather than null
+	if recipe.UsedIngredients == nil {
+		recipe.UsedIngredients = []DetailedIngredient{}
+	}
+	if recipe.MissedIngredients == nil {
+		recipe.MissedIngredients = []DetailedIngredient{}
+	}
+	if recipe.UnusedIngredients == nil {
+		recipe.UnusedIngredients = []string{}
+	}
+	recipe.MissingCount = len(recipe.MissedIngredients)
+}
+
+// markExpiring lists the ingredients close to expiry that a recipe uses up and sums how
+// urgently they need using. Urgency is keyed by canonical ingredient name.
+func markExpiring(recipe *Recipe, urgency map[string]float64) {
+	counted := make(map[string]bool)
+	for _, ingredientMatch := range recipe.Matches {
+		if !ingredientMatch.Matched() {
+			continue
+		}
+		name := normalizeIngredientName(ingredientMatch.MatchedBy)
+		if urgency[name] == 0 {
+			// Spoonacular's matches don't say which of the user's ingredients they used,
+			// and the matcher's may be spelled apart from the pantry's
+			name = urgentIngredient(ingredientMatch.Ingredient, urgency)
+		}
+		if urgency[name] == 0 || counted[name] {
+			continue
+		}
+		counted[name] = true
+		recipe.UsesExpiring = append(recipe.UsesExpiring, name)
+		recipe.ExpiryScore += urgency[name]
+	}
+	recipe.ExpiryScore = math.Round(recipe.ExpiryScore*100) / 100
+}
+
+// urgentIngredient returns the key in urgency that best covers a recipe ingredient, or
+// "" if none does
+func urgentIngredient(ingredient string, urgency map[string]float64) string {
+	names := make([]string, 0, len(urgency))
+	for name := range urgency {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best, bestScore := "", 0.0
+	for _, name := range names {
+		if match := defaultMatcher.Match(name, ingredient); match.Matched() && match.Score > bestScore {
+			best, bestScore = name, match.Score
+		}
+	}
+	return best
+}
+
+// reconcileMatch merges the matcher's verdicts with Spoonacular's breakdown: ingredients
+// Spoonacular counted as used stay used, and missed ones the matcher covers become used.
+// The user's ingredients that either side used are dropped from the unused list.
+func reconcileMatch(recipe *Recipe, match *RecipeMatch) {
+	usedByUpstream := make(map[string]bool, len(recipe.UsedIngredients))
+	for _, ingredient := range recipe.UsedIngredients {
+		usedByUpstream[normalizeIngredientName(ingredient.Name)] = true
+	}
+
+	matched := make(map[string]bool)
+	total := 0.0
+	for i := range match.Ingredients {
+		ingredientMatch := &match.Ingredients[i]
+		name := normalizeIngredientName(ingredientMatch.Ingredient)
+		if !ingredientMatch.Matched() && usedByUpstream[name] {
+			ingredientMatch.Kind = MatchUpstream
+			ingredientMatch.MatchedBy = ""
+			ingredientMatch.Score = matchScores[MatchUpstream]
+			ingredientMatch.Explanation = fmt.Sprintf("Spoonacular matched %s to one of your ingredients", ingredientMatch.Ingredient)
+		}
+		if ingredientMatch.Matched() {
+			matched[name] = true
+		}
+		total += ingredientMatch.Score
+	}
+	if len(match.Ingredients) > 0 {
+		match.Score = math.Round(total/float64(len(match.Ingredients))*100) / 100
+	}
+
+	// Build new lists: the old ones may be shared with the cached pool
+	used := append([]DetailedIngredient(nil), recipe.UsedIngredients...)
+	missed := make([]DetailedIngredient, 0, len(recipe.MissedIngredients))
+	for _, ingredient := range recipe.MissedIngredients {
+		if matched[normalizeIngredientName(ingredient.Name)] {
+			used = append(used, ingredient)
+		} else {
+			missed = append(missed, ingredient)
+		}
+	}
+	recipe.UsedIngredients, recipe.MissedIngredients = used, missed
+
+	unusedByMatcher := make(map[string]bool, len(match.Unused))
+	for _, ingredient := range match.Unused {
+		unusedByMatcher[normalizeIngredientName(ingredient)] = true
+	}
+	unused := make([]string, 0, len(recipe.UnusedIngredients))
+	for _, ingredient := range recipe.UnusedIngredients {
+		if unusedByMatcher[normalizeIngredientName(ingredient)] {
+			unused = append(unused, ingredient)
+		}
+	}
+	recipe.UnusedIngredients = unused
+}
+
+// sortRecipes orders recipes in place; ties keep Spoonacular's order. Recipes without a
+// known time or price sort last.
+func sortRecipes(recipes []Recipe, order RecipeSort) {
+	sort.SliceStable(recipes, func(i, j int) bool {
+		a, b := recipes[i], recipes[j]
+		switch order {
+		case SortMissing:
+			if a.MissingCount != b.MissingCount {
+				return a.MissingCount < b.MissingCount
+			}
+			return a.MatchCount > b.MatchCount
+		case SortTime:
+			return lessKnown(float64(a.ReadyInMinutes), float64(b.ReadyInMinutes))
+		case SortHealth:
+			return a.HealthScore > b.HealthScore
+		case SortPrice:
+			return lessKnown(a.PricePerServing, b.PricePerServing)
+		case SortExpiring:
+			if a.ExpiryScore != b.ExpiryScore {
+				return a.ExpiryScore > b.ExpiryScore
+			}
+			return betterMatch(a, b)
+		default:
+			return betterMatch(a, b)
+		}
+	})
+}
+
+// betterMatch orders recipes by how many of the user's ingredients they use, then by
+// how few others they need
+func betterMatch(a, b Recipe) bool {
+	if a.MatchCount != b.MatchCount {
+		return a.MatchCount > b.MatchCount
+	}
+	if a.MissingCount != b.MissingCount {
+		return a.MissingCount < b.MissingCount
+	}
+	return a.MatchScore > b.MatchScore
+}
+
+// lessKnown orders ascending with unknown (zero) values last
+func lessKnown(a, b float64) bool {
+	if a == 0 || b == 0 {
+		return a != 0 && b == 0
+	}
+	return a < b
+}
+
+// enrichRecipes fills in ready time, health score and price from recipe details, which
+// search results lack. Details come from the memory cache or storage when possible;
+// up to maxSortDetails of the rest are fetched in one informationBulk call and stored
+// for later, unless the quota only allows cached data. It returns how many recipes are
+// left without details.
+func (s *SpoonacularService) enrichRecipes(ctx context.Context, recipes []Recipe) int {
+	unsorted := 0
+	var missing []string
+	for i := range recipes {
+		if recipes[i].ReadyInMinutes != 0 || recipes[i].HealthScore != 0 || recipes[i].PricePerServing != 0 {
+			continue
+		}
+		id, err := ParseRecipeID(recipes[i].ID)
+		if err != nil || id.Source() != SourceSpoonacular {
+			unsorted++
+			continue
+		}
+		if details := s.storedRecipeDetails(id.Value()); details != nil {
+			applyRecipeDetails(&recipes[i], details)
+			continue
+		}
+		missing = append(missing, id.Value())
+	}
+	if len(missing) > maxSortDetails {
+		unsorted += len(missing) - maxSortDetails
+		missing = missing[:maxSortDetails]
+	}
+	if len(missing) == 0 {
+		return unsorted
+	}
+
+	if s.QuotaStatus().CacheOnly {
+		fmt.Printf("🪫 Not fetching details to sort %d recipes: %v\n", len(missing), ErrQuotaExhausted)
+		return unsorted + len(missing)
+	}
+	fetched, err := s.fetchRecipeDetailsBulk(ctx, missing)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Could not fetch details to sort %d recipes: %v\n", len(missing), err)
+		return unsorted + len(missing)
+	}
+	for i := range recipes {
+		if details, ok := fetched[recipes[i].ID]; ok {
+			applyRecipeDetails(&recipes[i], details)
+		}
+	}
+	return unsorted + len(missing) - len(fetched)
+}
+
+// storedRecipeDetails returns a recipe's details from the memory cache or storage,
+// however old, or nil
+func (s *SpoonacularService) storedRecipeDetails(recipeID string) *RecipeDetails {
+	if cachedData := s.getFromCache(fmt.Sprintf("recipe_details_%s", recipeID)); cachedData != nil {
+		if details, ok := cachedData.(*RecipeDetails); ok {
+			return details
+		}
+	}
+	details, _, err := s.storage.LoadRecipeDetails(recipeID)
+	if err != nil {
+		return nil
+	}
+	return details
+}
+
+// applyRecipeDetails copies the sortable facts of a recipe's details onto a search result
+func applyRecipeDetails(recipe *Recipe, details *RecipeDetails) {
+	recipe.ReadyInMinutes = details.ReadyInMinutes // Not TotalTime, which makes up a time when unknown
+	recipe.HealthScore = details.HealthScore
+	recipe.PricePerServing = details.PricePerServing
+}
+
+// fetchRecipeDetailsBulk calls the API for the details of several recipes at once and
+// stores each of them, returning them by ID
+func (s *SpoonacularService) fetchRecipeDetailsBulk(ctx context.Context, recipeIDs []string) (map[string]*RecipeDetails, error) {
+	apiURL := fmt.Sprintf("%s/recipes/informationBulk?apiKey=%s&ids=%s&includeNutrition=false",
+		s.baseURL, getSpoonacularAPIKey(), strings.Join(recipeIDs, ","))
+
+	fmt.Printf("🌐 Making Spoonacular API call for details of %d recipes\n", len(recipeIDs))
+
+	var recipeInfos []SpoonacularRecipeInfo
+	if err := s.upstream.getJSON(ctx, "informationBulk", apiURL, &recipeInfos); err != nil {
+		return nil, fmt.Errorf("failed to get recipe details: %w", err)
+	}
+
+	fetched := make(map[string]*RecipeDetails, len(recipeInfos))
+	for _, recipeInfo := range recipeInfos {
+		recipeDetails := s.convertToRecipeDetails(recipeInfo)
+		if err := s.storage.SaveRecipeDetails(recipeDetails, recipeDetails.ID); err != nil {
+			fmt.Printf("⚠️  Warning: Could not save recipe details to storage: %v\n", err)
+		}
+		s.setCache(fmt.Sprintf("recipe_details_%s", recipeDetails.ID), recipeDetails)
+		fetched[recipeDetails.ID] = recipeDetails
+	}
+
+	fmt.Printf("✅ Found details of %d recipes from Spoonacular API\n", len(fetched))
+	return fetched, nil
+}
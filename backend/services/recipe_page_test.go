@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEnrichRecipesCapsBulkFetch(t *testing.T) {
+	tests := []struct {
+		name         string
+		recipes      int
+		quotaSpent   bool
+		wantIDs      int // IDs asked for in the one informationBulk call, -1 if none is made
+		wantUnsorted int
+	}{
+		{"small pool", 10, false, 10, 10},
+		{"large pool", 48, false, maxSortDetails, 48},
+		{"quota spent", 10, true, -1, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The stand-in knows none of the recipes, so every one stays unsorted
+			var mu sync.Mutex
+			var requested [][]string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requested = append(requested, strings.Split(r.URL.Query().Get("ids"), ","))
+				mu.Unlock()
+				w.Write([]byte("[]"))
+			}))
+			t.Cleanup(server.Close)
+
+			s := NewSpoonacularService(newTestStorage(t))
+			t.Cleanup(s.Close)
+			s.SetBaseURL(server.URL)
+			if tt.quotaSpent {
+				spent := time.Now()
+				s.quota.usage.ExhaustedAt = &spent
+			}
+
+			recipes := make([]Recipe, tt.recipes)
+			for i := range recipes {
+				recipes[i].ID = strconv.Itoa(i + 1)
+			}
+			if got := s.enrichRecipes(context.Background(), recipes); got != tt.wantUnsorted {
+				t.Errorf("enrichRecipes() = %d unsorted, want %d", got, tt.wantUnsorted)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if tt.wantIDs < 0 {
+				if len(requested) != 0 {
+					t.Errorf("made %d informationBulk calls, want none", len(requested))
+				}
+				return
+			}
+			if len(requested) != 1 || len(requested[0]) != tt.wantIDs {
+				t.Fatalf("informationBulk calls asked for %v, want one call for %d IDs", requested, tt.wantIDs)
+			}
+			// The first recipes of the pool are the ones fetched
+			if requested[0][0] != "1" || requested[0][tt.wantIDs-1] != strconv.Itoa(tt.wantIDs) {
+				t.Errorf("informationBulk asked for %v, want IDs 1 to %d", requested[0], tt.wantIDs)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"recipe-finder-backend/units"
+)
+
+// ErrInvalidServings is returned when a recipe can't be scaled to the servings asked for
+var ErrInvalidServings = errors.New("invalid servings")
+
+// MaxServings bounds the servings a recipe can be scaled to
+const MaxServings = 100
+
+// ScaleRecipeDetails returns a copy of a recipe's details with every ingredient amount
+// scaled from the recipe's servings to servings. Amounts are rounded to fractions found
+// on measuring cups, metric amounts to sensible precision, and units move up or down
+// as the amount grows or shrinks. The details passed in are not changed, so cached
+// details can be scaled.
+func ScaleRecipeDetails(details *RecipeDetails, servings int) (*RecipeDetails, error) {
+	if servings < 1 || servings > MaxServings {
+		return nil, fmt.Errorf("%w: servings must be between 1 and %d", ErrInvalidServings, MaxServings)
+	}
+	if details.Servings < 1 {
+		return nil, fmt.Errorf("%w: recipe %s does not say how many it serves", ErrInvalidServings, details.ID)
+	}
+
+	scaled := *details
+	if servings == details.Servings {
+		return &scaled, nil
+	}
+
+	factor := float64(servings) / float64(details.Servings)
+	scaled.Ingredients = make([]DetailedIngredient, len(details.Ingredients))
+	for i, ingredient := range details.Ingredients {
+		if ingredient.Amount > 0 {
+			ingredient.Amount *= factor
+			ingredient.AmountMax *= factor
+			ingredient = requantify(ingredient, units.Best)
+		}
+		scaled.Ingredients[i] = ingredient
+	}
+	scaled.ScaledFrom = details.Servings
+	scaled.Servings = servings
+	return &scaled, nil
+}
+
+// requantify moves an ingredient's amount to the unit chosen by convert, rounds it to
+// what can be measured and rewrites the ingredient's original line to match. Amounts
+// in units the units package doesn't know are only rounded.
+func requantify(ingredient DetailedIngredient, convert func(units.Quantity) units.Quantity) DetailedIngredient {
+	unit, known := units.Lookup(ingredient.Unit)
+	if !known || unit.Dimension == units.Count {
+		ingredient.Amount = units.Round(units.Quantity{Amount: ingredient.Amount, Unit: unit}).Amount
+		if ingredient.AmountMax > 0 {
+			ingredient.AmountMax = units.Round(units.Quantity{Amount: ingredient.AmountMax, Unit: unit}).Amount
+		}
+		ingredient.Original = formatIngredientLine(ingredient)
+		return ingredient
+	}
+
+	// Both ends of a range go to the unit chosen for the low end
+	converted := convert(units.Quantity{Amount: ingredient.Amount, Unit: unit})
+	amount := units.Round(converted)
+	if ingredient.AmountMax > 0 {
+		amountMax := units.Quantity{Amount: ingredient.AmountMax * converted.Amount / ingredient.Amount, Unit: converted.Unit}
+		ingredient.AmountMax = units.Round(amountMax).Amount
+	}
+	ingredient.Amount = amount.Amount
+	ingredient.Unit = amount.Unit.Symbol
+	ingredient.UnitLong = amount.Unit.Label(math.Max(ingredient.Amount, ingredient.AmountMax))
+	ingredient.Original = formatIngredientLine(ingredient)
+	return ingredient
+}
+
+// formatIngredientLine writes an ingredient back out as a line, e.g. "1 1/2 cups onions,
+// finely chopped"
+func formatIngredientLine(ingredient DetailedIngredient) string {
+	unit, _ := units.Lookup(ingredient.Unit)
+	parts := []string{units.Format(units.Quantity{Amount: ingredient.Amount, Unit: unit})}
+	if ingredient.AmountMax > 0 {
+		parts[0] += "-" + units.Format(units.Quantity{Amount: ingredient.AmountMax, Unit: unit})
+	}
+	if ingredient.UnitLong != "" {
+		parts = append(parts, ingredient.UnitLong)
+	} else if ingredient.Unit != "" {
+		parts = append(parts, ingredient.Unit)
+	}
+	name := ingredient.OriginalName
+	if name == "" {
+		name = ingredient.Name
+	}
+	parts = append(parts, name)
+
+	line := strings.Join(parts, " ")
+	if len(ingredient.Meta) > 0 {
+		line += ", " + strings.Join(ingredient.Meta, ", ")
+	}
+	return line
+}
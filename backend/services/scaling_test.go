@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"recipe-finder-backend/units"
+)
+
+// testRecipeDetails returns a recipe serving 4 with ingredients in several kinds of unit
+func testRecipeDetails() *RecipeDetails {
+	return &RecipeDetails{
+		ID:       "1",
+		Title:    "Test Cake",
+		Servings: 4,
+		Ingredients: []DetailedIngredient{
+			{Name: "flour", OriginalName: "flour", Amount: 2, Unit: "cups", Original: "2 cups flour"},
+			{Name: "butter", OriginalName: "butter", Amount: 3, Unit: "tbsp", Original: "3 tbsp butter, melted", Meta: []string{"melted"}},
+			{Name: "garlic", OriginalName: "garlic", Amount: 2, AmountMax: 3, Unit: "cloves", Original: "2-3 cloves garlic"},
+			{Name: "eggs", OriginalName: "eggs", Amount: 3, Original: "3 eggs"},
+			{Name: "milk", OriginalName: "milk", Amount: 250, Unit: "ml", Original: "250 ml milk"},
+			{Name: "salt", OriginalName: "salt", Original: "salt to taste", Meta: []string{"to taste"}},
+		},
+		Instructions: []Instruction{{Number: 1, Step: "Bake at 350°F for 30 minutes."}},
+	}
+}
+
+func TestScaleRecipeDetails(t *testing.T) {
+	tests := []struct {
+		name     string
+		servings int
+		want     []string // Each ingredient's line after scaling
+	}{
+		{"same servings", 4, []string{"2 cups flour", "3 tbsp butter, melted", "2-3 cloves garlic", "3 eggs", "250 ml milk", "salt to taste"}},
+		{"doubled", 8, []string{"4 cups flour", "1/3 cup butter, melted", "4-6 cloves garlic", "6 eggs", "500 milliliters milk", "salt to taste"}},
+		{"halved", 2, []string{"1 cup flour", "1 1/2 tablespoons butter, melted", "1-1 1/2 cloves garlic", "1 1/2 eggs", "125 milliliters milk", "salt to taste"}},
+		{"quartered", 1, []string{"1/2 cup flour", "2 1/4 teaspoons butter, melted", "1/2-3/4 cloves garlic", "3/4 eggs", "63 milliliters milk", "salt to taste"}},
+		{"many", 40, []string{"20 cups flour", "2 cups butter, melted", "20-30 cloves garlic", "30 eggs", "2.5 liters milk", "salt to taste"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := testRecipeDetails()
+			scaled, err := ScaleRecipeDetails(details, tt.servings)
+			if err != nil {
+				t.Fatalf("ScaleRecipeDetails() = %v", err)
+			}
+			if scaled.Servings != tt.servings {
+				t.Errorf("servings = %d, want %d", scaled.Servings, tt.servings)
+			}
+			for i, ingredient := range scaled.Ingredients {
+				if ingredient.Original != tt.want[i] {
+					t.Errorf("ingredient %d = %q, want %q", i, ingredient.Original, tt.want[i])
+				}
+			}
+			if details.Ingredients[0].Amount != 2 || details.Servings != 4 {
+				t.Errorf("the details passed in were changed")
+			}
+		})
+	}
+}
+
+func TestScaleRecipeDetailsInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		servings int
+		recipe   int // The recipe's own servings
+	}{
+		{"zero servings", 0, 4},
+		{"negative servings", -2, 4},
+		{"too many servings", MaxServings + 1, 4},
+		{"recipe without servings", 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := testRecipeDetails()
+			details.Servings = tt.recipe
+			if _, err := ScaleRecipeDetails(details, tt.servings); !errors.Is(err, ErrInvalidServings) {
+				t.Errorf("ScaleRecipeDetails(%d) = %v, want ErrInvalidServings", tt.servings, err)
+			}
+		})
+	}
+}
+
+func TestConvertRecipeDetails(t *testing.T) {
+	tests := []struct {
+		system   units.System
+		want     []string
+		wantStep string
+	}{
+		{units.Original, []string{"2 cups flour", "3 tbsp butter, melted", "2-3 cloves garlic", "3 eggs", "250 ml milk", "salt to taste"},
+			"Bake at 350°F for 30 minutes."},
+		{units.Metric, []string{"250 grams flour", "43 grams butter, melted", "2-3 cloves garlic", "3 eggs", "250 ml milk", "salt to taste"},
+			"Bake at 175°C for 30 minutes."},
+		{units.US, []string{"2 cups flour", "3 tbsp butter, melted", "2-3 cloves garlic", "3 eggs", "1 cup milk", "salt to taste"},
+			"Bake at 350°F for 30 minutes."},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.system), func(t *testing.T) {
+			converted := ConvertRecipeDetails(testRecipeDetails(), tt.system)
+			for i, ingredient := range converted.Ingredients {
+				if ingredient.Original != tt.want[i] {
+					t.Errorf("ingredient %d = %q, want %q", i, ingredient.Original, tt.want[i])
+				}
+			}
+			if step := converted.Instructions[0].Step; step != tt.wantStep {
+				t.Errorf("step = %q, want %q", step, tt.wantStep)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"recipe-finder-backend/models"
+)
+
+const (
+	// DefaultSearchLimit is the page size used when a search request does not set one
+	DefaultSearchLimit = 20
+	// MaxSearchLimit caps the page size of a search request
+	MaxSearchLimit = 100
+)
+
+// SearchService runs filtered searches over user-authored recipes and cached Spoonacular
+// data using the storage search index
+type SearchService struct {
+	storage *StorageService
+}
+
+// SearchResult is a single recipe matching a search, from either source
+type SearchResult struct {
+	ID          string   `json:"id"`
+	Source      string   `json:"source"` // "local" or "spoonacular"
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Ingredients []string `json:"ingredients"`
+	PrepTime    int      `json:"prepTime"` // in minutes
+	CookTime    int      `json:"cookTime"` // in minutes
+	Servings    int      `json:"servings"`
+	Difficulty  string   `json:"difficulty"`
+	Categories  []string `json:"categories"`
+	Tags        []string `json:"tags"`
+	ImageURL    string   `json:"imageUrl"`
+	Score       float64  `json:"score"`
+
+	instructions []string
+}
+
+// SearchPage is one page of ranked search results
+type SearchPage struct {
+	Results []SearchResult `json:"results"`
+	Total   int            `json:"total"`
+	Limit   int            `json:"limit"`
+	Offset  int            `json:"offset"`
+}
+
+// NewSearchService creates a search service over the given storage
+func NewSearchService(storage *StorageService) *SearchService {
+	return &SearchService{storage: storage}
+}
+
+// Search applies every filter of the request, ranks the matches and returns the requested
+// page. A query made only of stop words is a validation error.
+func (s *SearchService) Search(req models.SearchRecipeRequest) (*SearchPage, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	index := s.storage.Index()
+	hasQuery := strings.TrimSpace(req.Query) != ""
+	if hasQuery && len(tokenize(req.Query)) == 0 {
+		// A query of stop words alone would silently match nothing
+		return nil, &models.ValidationError{Fields: map[string]string{
+			"query": "must contain a word to search for besides common words like \"the\" or \"with\"",
+		}}
+	}
+	var scores map[string]float64
+	if hasQuery {
+		scores = index.Score(req.Query)
+	}
+
+	matches := make([]SearchResult, 0)
+	for _, candidate := range index.Documents() {
+		if !matchesFilters(candidate, req) {
+			continue
+		}
+		if hasQuery {
+			candidate.Score = math.Round(scores[indexKey(candidate.Source, candidate.ID)]*1000) / 1000
+			if candidate.Score == 0 {
+				continue
+			}
+		}
+		matches = append(matches, candidate)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return strings.ToLower(matches[i].Title) < strings.ToLower(matches[j].Title)
+	})
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	page := &SearchPage{
+		Results: []SearchResult{},
+		Total:   len(matches),
+		Limit:   limit,
+		Offset:  req.Offset,
+	}
+	if req.Offset < len(matches) {
+		end := req.Offset + limit
+		if end > len(matches) {
+			end = len(matches)
+		}
+		page.Results = matches[req.Offset:end]
+	}
+
+	return page, nil
+}
+
+// searchResultFromLocal converts a user-authored recipe
+func searchResultFromLocal(recipe *models.Recipe) SearchResult {
+	categories := []string{}
+	if recipe.Category != "" {
+		categories = append(categories, recipe.Category)
+	}
+	return SearchResult{
+		ID:           recipe.ID,
+		Source:       "local",
+		Title:        recipe.Title,
+		Description:  recipe.Description,
+		Ingredients:  recipe.Ingredients,
+		PrepTime:     recipe.PrepTime,
+		CookTime:     recipe.CookTime,
+		Servings:     recipe.Servings,
+		Difficulty:   recipe.Difficulty,
+		Categories:   categories,
+		Tags:         recipe.Tags,
+		ImageURL:     recipe.ImageURL,
+		instructions: recipe.Instructions,
+	}
+}
+
+// searchResultFromDetails converts cached Spoonacular recipe details
+func searchResultFromDetails(details *RecipeDetails) SearchResult {
+	ingredients := make([]string, 0, len(details.Ingredients))
+	for _, ing := range details.Ingredients {
+		ingredients = append(ingredients, ing.Name)
+	}
+	instructions := make([]string, 0, len(details.Instructions))
+	for _, step := range details.Instructions {
+		instructions = append(instructions, step.Step)
+	}
+
+	categories := append(append([]string{}, details.Cuisines...), details.DishTypes...)
+
+	tags := append([]string{}, details.Diets...)
+	tags = append(tags, details.Occasions...)
+	for tag, set := range map[string]bool{
+		"vegetarian":   details.IsVegetarian,
+		"vegan":        details.IsVegan,
+		"gluten free":  details.IsGlutenFree,
+		"dairy free":   details.IsDairyFree,
+		"very healthy": details.IsVeryHealthy,
+		"cheap":        details.IsCheap,
+		"popular":      details.IsPopular,
+		"sustainable":  details.IsSustainable,
+	} {
+		if set {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+
+	prepTime := parseMinutes(details.PrepTime)
+	cookTime := parseMinutes(details.CookTime)
+	return SearchResult{
+		ID:           details.ID,
+		Source:       "spoonacular",
+		Title:        details.Title,
+		Description:  details.Description,
+		Ingredients:  ingredients,
+		PrepTime:     prepTime,
+		CookTime:     cookTime,
+		Servings:     details.Servings,
+		Difficulty:   difficultyForMinutes(parseMinutes(details.TotalTime)),
+		Categories:   categories,
+		Tags:         tags,
+		ImageURL:     details.ImageURL,
+		instructions: instructions,
+	}
+}
+
+// searchResultFromSummary converts a cached Spoonacular search result
+func searchResultFromSummary(recipe Recipe) SearchResult {
+	prepTime := parseMinutes(recipe.PrepTime)
+	cookTime := parseMinutes(recipe.CookTime)
+	return SearchResult{
+		ID:          recipe.ID,
+		Source:      "spoonacular",
+		Title:       recipe.Title,
+		Description: recipe.Description,
+		Ingredients: recipe.Ingredients,
+		PrepTime:    prepTime,
+		CookTime:    cookTime,
+		Servings:    recipe.Servings,
+		Difficulty:  difficultyForMinutes(prepTime + cookTime),
+		Categories:  []string{},
+		Tags:        []string{},
+		ImageURL:    recipe.ImageURL,
+	}
+}
+
+// matchesFilters reports whether a candidate passes every non-query filter of the request
+func matchesFilters(candidate SearchResult, req models.SearchRecipeRequest) bool {
+	if req.Category != "" && !containsLabel(candidate.Categories, req.Category) {
+		return false
+	}
+	if req.Difficulty != "" && !strings.EqualFold(candidate.Difficulty, req.Difficulty) {
+		return false
+	}
+	for _, tag := range req.Tags {
+		if strings.TrimSpace(tag) != "" && !containsLabel(candidate.Tags, tag) {
+			return false
+		}
+	}
+	if req.MaxPrepTime > 0 && candidate.PrepTime > req.MaxPrepTime {
+		return false
+	}
+	if req.MaxCookTime > 0 && candidate.CookTime > req.MaxCookTime {
+		return false
+	}
+	return true
+}
+
+// containsLabel compares category and tag labels ignoring case, dashes and underscores
+func containsLabel(labels []string, wanted string) bool {
+	wanted = normalizeLabel(wanted)
+	for _, label := range labels {
+		if normalizeLabel(label) == wanted {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeLabel lowercases a label and treats "-" and "_" as spaces
+func normalizeLabel(label string) string {
+	label = strings.ToLower(strings.TrimSpace(label))
+	return strings.NewReplacer("-", " ", "_", " ").Replace(label)
+}
+
+// parseMinutes reads durations formatted like "15 min", returning 0 if unknown
+func parseMinutes(value string) int {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0
+	}
+	minutes, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0
+	}
+	return minutes
+}
+
+// difficultyForMinutes estimates a difficulty level from total time for sources that have none
+func difficultyForMinutes(minutes int) string {
+	switch {
+	case minutes <= 0:
+		return ""
+	case minutes <= 30:
+		return "easy"
+	case minutes <= 60:
+		return "medium"
+	default:
+		return "hard"
+	}
+}
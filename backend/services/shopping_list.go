@@ -0,0 +1,394 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"recipe-finder-backend/units"
+)
+
+// otherAisle holds ingredients whose aisle is unknown, like those of my recipes
+const otherAisle = "Other"
+
+// ShoppingList is the combined ingredients of several recipes, grouped by aisle
+type ShoppingList struct {
+	Recipes     []ShoppingListRecipe `json:"recipes"`
+	Aisles      []ShoppingAisle      `json:"aisles"`
+	AlreadyHave []string             `json:"alreadyHave"` // Ingredients left off because the user has enough
+	Units       string               `json:"units"`
+}
+
+// ShoppingListRecipe names a recipe a shopping list was built for
+type ShoppingListRecipe struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Servings int    `json:"servings"`
+}
+
+// ShoppingAisle is the part of a shopping list found in one aisle
+type ShoppingAisle struct {
+	Aisle string         `json:"aisle"`
+	Items []ShoppingItem `json:"items"`
+}
+
+// ShoppingItem is one ingredient to buy. Amounts that can't be added up, like cloves and
+// grams of garlic, are listed separately; an item without quantities has no amount, as
+// with "salt to taste".
+type ShoppingItem struct {
+	Name       string             `json:"name"`
+	Quantities []ShoppingQuantity `json:"quantities"`
+	Line       string             `json:"line"`    // e.g. "2 1/2 cups flour"
+	Recipes    []string           `json:"recipes"` // Titles of the recipes that need it
+}
+
+// ShoppingQuantity is an amount of an item in one unit
+type ShoppingQuantity struct {
+	Amount   float64 `json:"amount"`
+	Unit     string  `json:"unit"`
+	UnitLong string  `json:"unitLong"`
+}
+
+// shoppingEntry adds up one ingredient across recipes. Volumes are kept in milliliters
+// and masses in grams; counted amounts are kept per unit, "" being a plain count.
+type shoppingEntry struct {
+	name         string
+	aisle        string
+	recipes      []string
+	volume, mass float64
+	volumeSystem units.System // The system the first volume was written in
+	massSystem   units.System
+	counts       map[string]float64
+	countUnits   []string // Count units in the order first seen
+}
+
+// BuildShoppingList combines the ingredients of recipes, already scaled to the servings
+// wanted, into one list. Ingredients are merged by name, synonyms included, and their
+// amounts added up across units: cups and tablespoons, ounces and grams, and volumes and
+// masses of ingredients with a known density. Anything in have, a list of ingredient
+// lines such as "salt" or "1 cup flour", is subtracted, each amount used up by the first
+// entries it covers; ingredients the user has without saying how much are left off
+// entirely. Amounts are given in system, or for Original in
+// the system each ingredient was first written in.
+func BuildShoppingList(recipes []*RecipeDetails, have []string, system units.System) *ShoppingList {
+	list := &ShoppingList{
+		Recipes:     make([]ShoppingListRecipe, 0, len(recipes)),
+		Aisles:      []ShoppingAisle{},
+		AlreadyHave: []string{},
+		Units:       string(system),
+	}
+
+	entries := make(map[string]*shoppingEntry)
+	var order []string
+	for _, recipe := range recipes {
+		list.Recipes = append(list.Recipes, ShoppingListRecipe{ID: recipe.ID, Title: recipe.Title, Servings: recipe.Servings})
+		for _, ingredient := range recipe.Ingredients {
+			if ingredient.Name == "" {
+				continue
+			}
+			key := defaultMatcher.term(ingredient.Name).canonical
+			entry, ok := entries[key]
+			if !ok {
+				entry = &shoppingEntry{name: ingredient.Name, counts: make(map[string]float64)}
+				entries[key] = entry
+				order = append(order, key)
+			}
+			entry.add(ingredient, recipe.Title)
+		}
+	}
+
+	for _, line := range have {
+		owned := ParseIngredientLine(line)
+		if owned.Name == "" {
+			continue
+		}
+		unlimited := owned.Amount <= 0
+		for _, key := range order {
+			entry := entries[key]
+			if entry == nil || !coversItem(owned.Name, entry.name) {
+				continue
+			}
+			bought, left := entry.subtract(owned)
+			if bought {
+				list.AlreadyHave = append(list.AlreadyHave, entry.name)
+				entries[key] = nil
+			}
+			if !unlimited {
+				if owned.Amount = left; owned.Amount <= 0 {
+					break
+				}
+			}
+		}
+	}
+
+	aisles := make(map[string][]ShoppingItem)
+	for _, key := range order {
+		if entry := entries[key]; entry != nil {
+			aisles[entry.aisle] = append(aisles[entry.aisle], entry.item(system))
+		}
+	}
+	for aisle, items := range aisles {
+		sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+		list.Aisles = append(list.Aisles, ShoppingAisle{Aisle: aisle, Items: items})
+	}
+	sort.Slice(list.Aisles, func(i, j int) bool {
+		a, b := list.Aisles[i].Aisle, list.Aisles[j].Aisle
+		if (a == otherAisle) != (b == otherAisle) {
+			return b == otherAisle
+		}
+		return a < b
+	})
+	return list
+}
+
+// coversItem reports whether what the user has is the ingredient a recipe needs: the
+// same, another name for it or a more specific kind of it
+func coversItem(owned, needed string) bool {
+	switch defaultMatcher.Match(owned, needed).Kind {
+	case MatchExact, MatchSynonym, MatchSpecific:
+		return true
+	}
+	return false
+}
+
+// add counts one recipe's amount of the ingredient. Ranges count at their upper bound.
+func (e *shoppingEntry) add(ingredient DetailedIngredient, recipeTitle string) {
+	if e.aisle == "" || e.aisle == otherAisle {
+		e.aisle = aisleOf(ingredient.Aisle)
+	}
+	if len(e.recipes) == 0 || e.recipes[len(e.recipes)-1] != recipeTitle {
+		e.recipes = append(e.recipes, recipeTitle)
+	}
+
+	amount := ingredient.Amount
+	if ingredient.AmountMax > amount {
+		amount = ingredient.AmountMax
+	}
+	if amount <= 0 {
+		return
+	}
+
+	unit, known := units.Lookup(ingredient.Unit)
+	switch {
+	case known && unit.Dimension == units.Volume:
+		ml, _ := units.Convert(units.Quantity{Amount: amount, Unit: unit}, units.Milliliter)
+		e.volume += ml.Amount
+		if e.volumeSystem == "" {
+			e.volumeSystem = unit.System
+		}
+	case known && unit.Dimension == units.Mass:
+		g, _ := units.Convert(units.Quantity{Amount: amount, Unit: unit}, units.Gram)
+		e.mass += g.Amount
+		if e.massSystem == "" {
+			e.massSystem = unit.System
+		}
+	case known && unit.Dimension == units.Temperature:
+		// Not something to buy
+	default:
+		symbol := strings.ToLower(ingredient.Unit)
+		if known {
+			symbol = unit.Symbol
+		}
+		if _, seen := e.counts[symbol]; !seen {
+			e.countUnits = append(e.countUnits, symbol)
+		}
+		e.counts[symbol] += amount
+	}
+}
+
+// subtract takes what the user has off the entry, reporting whether nothing is left to
+// buy and how much of owned, in its own unit, the entry didn't need. Amounts in units
+// that can't be compared with the entry's are ignored and left over whole.
+func (e *shoppingEntry) subtract(owned DetailedIngredient) (bool, float64) {
+	if owned.Amount <= 0 {
+		return true, 0
+	}
+
+	unit, known := units.Lookup(owned.Unit)
+	quantity := units.Quantity{Amount: owned.Amount, Unit: unit}
+	density, hasDensity := units.DensityOf(e.name)
+	leftOver := 1.0 // The share of owned the entry didn't need
+	switch {
+	case known && unit.Dimension == units.Volume:
+		ml, _ := units.Convert(quantity, units.Milliliter)
+		if e.volume == 0 && e.mass > 0 && hasDensity {
+			leftOver = consume(&e.mass, ml.Amount*density.GramsPerMilliliter)
+		} else {
+			leftOver = consume(&e.volume, ml.Amount)
+		}
+	case known && unit.Dimension == units.Mass:
+		g, _ := units.Convert(quantity, units.Gram)
+		if e.mass == 0 && e.volume > 0 && hasDensity {
+			leftOver = consume(&e.volume, g.Amount/density.GramsPerMilliliter)
+		} else {
+			leftOver = consume(&e.mass, g.Amount)
+		}
+	default:
+		symbol := strings.ToLower(owned.Unit)
+		if known {
+			symbol = unit.Symbol
+		}
+		if _, ok := e.counts[symbol]; !ok && symbol == "" {
+			// "2 eggs" counts against "3 large eggs"
+			for _, size := range []string{"large", "medium", "small"} {
+				if _, ok := e.counts[size]; ok {
+					symbol = size
+					break
+				}
+			}
+		}
+		if count, ok := e.counts[symbol]; ok {
+			leftOver = consume(&count, owned.Amount)
+			e.counts[symbol] = count
+		}
+	}
+
+	left := owned.Amount * leftOver
+	if e.volume > 0 || e.mass > 0 {
+		return false, left
+	}
+	for _, amount := range e.counts {
+		if amount > 0 {
+			return false, left
+		}
+	}
+	return true, left
+}
+
+// consume takes up to offered off needed, returning the share of offered left over
+func consume(needed *float64, offered float64) float64 {
+	if offered <= 0 {
+		return 0
+	}
+	taken := math.Min(*needed, offered)
+	*needed = remaining(*needed, taken)
+	return remaining(offered, taken) / offered
+}
+
+// remaining subtracts, treating what's left of rounding errors as nothing
+func remaining(amount, owned float64) float64 {
+	if left := amount - owned; left > amount*1e-6 {
+		return left
+	}
+	return 0
+}
+
+// item presents the entry's amounts in a measurement system. Volumes of an ingredient
+// with a known density are added to its mass when it has one, and weighed outright in
+// metric unless the ingredient is a liquid.
+func (e *shoppingEntry) item(system units.System) ShoppingItem {
+	volume, mass := e.volume, e.mass
+	if density, ok := units.DensityOf(e.name); ok && volume > 0 && (mass > 0 || system == units.Metric && !density.Liquid) {
+		mass += volume * density.GramsPerMilliliter
+		volume = 0
+		if e.massSystem == "" {
+			e.massSystem = e.volumeSystem
+		}
+	}
+
+	item := ShoppingItem{Name: e.name, Quantities: []ShoppingQuantity{}, Recipes: e.recipes}
+	if mass > 0 {
+		item.Quantities = append(item.Quantities, shoppingQuantity(units.Quantity{Amount: mass, Unit: units.Gram}, pickSystem(system, e.massSystem)))
+	}
+	if volume > 0 {
+		item.Quantities = append(item.Quantities, shoppingQuantity(units.Quantity{Amount: volume, Unit: units.Milliliter}, pickSystem(system, e.volumeSystem)))
+	}
+	for _, symbol := range e.countUnits {
+		if amount := e.counts[symbol]; amount > 0 {
+			quantity := units.Round(units.Quantity{Amount: amount})
+			unit, _ := units.Lookup(symbol)
+			unitLong := symbol
+			if !unit.IsZero() {
+				unitLong = unit.Label(quantity.Amount)
+			}
+			item.Quantities = append(item.Quantities, ShoppingQuantity{Amount: quantity.Amount, Unit: symbol, UnitLong: unitLong})
+		}
+	}
+
+	amounts := make([]string, 0, len(item.Quantities))
+	for _, quantity := range item.Quantities {
+		unit, _ := units.Lookup(quantity.Unit)
+		amount := units.Format(units.Quantity{Amount: quantity.Amount, Unit: unit})
+		if quantity.UnitLong != "" {
+			amount += " " + quantity.UnitLong
+		}
+		amounts = append(amounts, amount)
+	}
+	item.Line = strings.TrimSpace(strings.Join(amounts, " + ") + " " + e.name)
+	return item
+}
+
+// pickSystem returns the system asked for, or for Original the one first seen
+func pickSystem(asked, seen units.System) units.System {
+	if asked == units.Metric || asked == units.US {
+		return asked
+	}
+	if seen == units.US {
+		return units.US
+	}
+	return units.Metric
+}
+
+// shoppingQuantity writes a mass or volume in the unit of system that suits it
+func shoppingQuantity(q units.Quantity, system units.System) ShoppingQuantity {
+	q = units.Round(units.ToSystem(q, system, ""))
+	return ShoppingQuantity{Amount: q.Amount, Unit: q.Unit.Symbol, UnitLong: q.Unit.Label(q.Amount)}
+}
+
+// aisleOf returns the first of Spoonacular's aisles for an ingredient, e.g. "Baking" of
+// "Baking;Spices and Seasonings"
+func aisleOf(aisle string) string {
+	if first, _, _ := strings.Cut(aisle, ";"); strings.TrimSpace(first) != "" && first != "?" {
+		return strings.TrimSpace(first)
+	}
+	return otherAisle
+}
+
+// Text renders the shopping list as plain text
+func (l *ShoppingList) Text() string {
+	var b strings.Builder
+	b.WriteString("Shopping list for " + l.recipeSummary(false) + "\n")
+	for _, aisle := range l.Aisles {
+		b.WriteString("\n" + aisle.Aisle + "\n")
+		for _, item := range aisle.Items {
+			b.WriteString("- " + item.Line + "\n")
+		}
+	}
+	if len(l.AlreadyHave) > 0 {
+		b.WriteString("\nAlready have: " + strings.Join(l.AlreadyHave, ", ") + "\n")
+	}
+	return b.String()
+}
+
+// Markdown renders the shopping list as a Markdown checklist
+func (l *ShoppingList) Markdown() string {
+	var b strings.Builder
+	b.WriteString("# Shopping list\n\nFor " + l.recipeSummary(true) + "\n")
+	for _, aisle := range l.Aisles {
+		b.WriteString("\n## " + aisle.Aisle + "\n\n")
+		for _, item := range aisle.Items {
+			b.WriteString("- [ ] " + item.Line + "\n")
+		}
+	}
+	if len(l.AlreadyHave) > 0 {
+		b.WriteString("\n## Already have\n\n")
+		for _, name := range l.AlreadyHave {
+			b.WriteString("- [x] " + name + "\n")
+		}
+	}
+	return b.String()
+}
+
+// recipeSummary lists the recipes and servings, e.g. "Pasta (6 servings), Soup (4 servings)"
+func (l *ShoppingList) recipeSummary(bold bool) string {
+	parts := make([]string, 0, len(l.Recipes))
+	for _, recipe := range l.Recipes {
+		title := recipe.Title
+		if bold {
+			title = "**" + title + "**"
+		}
+		parts = append(parts, fmt.Sprintf("%s (%d servings)", title, recipe.Servings))
+	}
+	return strings.Join(parts, ", ")
+}
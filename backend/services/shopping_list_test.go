@@ -0,0 +1,129 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"recipe-finder-backend/units"
+)
+
+func TestBuildShoppingList(t *testing.T) {
+	pasta := &RecipeDetails{ID: "1", Title: "Pasta", Servings: 2, Ingredients: []DetailedIngredient{
+		{Name: "spaghetti", Amount: 200, Unit: "g", Aisle: "Pasta and Rice"},
+		{Name: "garlic", Amount: 2, Unit: "cloves", Aisle: "Produce"},
+		{Name: "olive oil", Amount: 2, Unit: "tbsp", Aisle: "Oil, Vinegar, Salad Dressing"},
+		{Name: "parmesan", Amount: 50, Unit: "g", Aisle: "Cheese"},
+		{Name: "salt"},
+	}}
+	salad := &RecipeDetails{ID: "2", Title: "Salad", Servings: 2, Ingredients: []DetailedIngredient{
+		{Name: "olive oil", Amount: 0.25, Unit: "cup", Aisle: "Oil, Vinegar, Salad Dressing"},
+		{Name: "garlic", Amount: 1, Unit: "clove", Aisle: "Produce"},
+		{Name: "parmesan", Amount: 1, Unit: "oz", Aisle: "Cheese"},
+		{Name: "eggs", Amount: 2, Unit: "large", Aisle: "Milk, Eggs, Other Dairy"},
+		{Name: "cheese", Amount: 100, Unit: "g", Aisle: "Cheese"},
+	}}
+
+	tests := []struct {
+		name        string
+		recipes     []*RecipeDetails
+		have        []string
+		system      units.System
+		want        map[string]string // Item lines by name
+		alreadyHave []string
+	}{
+		{
+			name:    "one recipe",
+			recipes: []*RecipeDetails{pasta},
+			system:  units.Original,
+			want: map[string]string{
+				"spaghetti": "200 grams spaghetti", "garlic": "2 cloves garlic",
+				"olive oil": "2 tablespoons olive oil", "parmesan": "50 grams parmesan", "salt": "salt",
+			},
+			alreadyHave: []string{},
+		},
+		{
+			name:    "merged across recipes and units",
+			recipes: []*RecipeDetails{pasta, salad},
+			system:  units.Metric,
+			want: map[string]string{
+				"spaghetti": "200 grams spaghetti", "garlic": "3 cloves garlic",
+				"olive oil": "89 milliliters olive oil", "parmesan": "78 grams parmesan", "salt": "salt",
+				"eggs": "2 large eggs", "cheese": "100 grams cheese",
+			},
+			alreadyHave: []string{},
+		},
+		{
+			name:    "owned amounts subtracted",
+			recipes: []*RecipeDetails{pasta, salad},
+			have:    []string{"salt", "1 clove garlic", "100 g spaghetti", "1 egg", "2 tbsp olive oil"},
+			system:  units.Metric,
+			want: map[string]string{
+				"spaghetti": "100 grams spaghetti", "garlic": "2 cloves garlic",
+				"olive oil": "59 milliliters olive oil", "parmesan": "78 grams parmesan",
+				"eggs": "1 large eggs", "cheese": "100 grams cheese",
+			},
+			alreadyHave: []string{"salt"},
+		},
+		{
+			name: "owned amount used up once",
+			recipes: []*RecipeDetails{
+				{ID: "3", Title: "Stew", Servings: 4, Ingredients: []DetailedIngredient{{Name: "chicken", Amount: 300, Unit: "g"}}},
+				{ID: "4", Title: "Wraps", Servings: 4, Ingredients: []DetailedIngredient{{Name: "chicken breast", Amount: 300, Unit: "g"}}},
+			},
+			have:        []string{"400 g chicken breast"},
+			system:      units.Metric,
+			want:        map[string]string{"chicken breast": "200 grams chicken breast"},
+			alreadyHave: []string{"chicken"},
+		},
+		{
+			name:        "enough owned",
+			recipes:     []*RecipeDetails{pasta},
+			have:        []string{"1 lb spaghetti", "garlic", "1 cup olive oil", "parmesan", "salt"},
+			system:      units.Original,
+			want:        map[string]string{},
+			alreadyHave: []string{"spaghetti", "garlic", "olive oil", "parmesan", "salt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := BuildShoppingList(tt.recipes, tt.have, tt.system)
+			got := make(map[string]string)
+			for _, aisle := range list.Aisles {
+				for _, item := range aisle.Items {
+					got[item.Name] = item.Line
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("items = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(list.AlreadyHave, tt.alreadyHave) {
+				t.Errorf("already have = %q, want %q", list.AlreadyHave, tt.alreadyHave)
+			}
+			if len(list.Recipes) != len(tt.recipes) {
+				t.Errorf("%d recipes listed, want %d", len(list.Recipes), len(tt.recipes))
+			}
+		})
+	}
+}
+
+func TestBuildShoppingListAisles(t *testing.T) {
+	recipe := &RecipeDetails{ID: "1", Title: "Toast", Servings: 1, Ingredients: []DetailedIngredient{
+		{Name: "bread", Amount: 2, Unit: "slices", Aisle: "Bakery/Bread"},
+		{Name: "butter", Amount: 1, Unit: "tbsp", Aisle: "Milk, Eggs, Other Dairy"},
+		{Name: "jam", Amount: 1, Unit: "tbsp"},
+	}}
+	list := BuildShoppingList([]*RecipeDetails{recipe}, nil, units.Original)
+
+	var aisles []string
+	for _, aisle := range list.Aisles {
+		aisles = append(aisles, aisle.Aisle)
+	}
+	if last := aisles[len(aisles)-1]; last != otherAisle {
+		t.Errorf("aisles = %q, want %q last", aisles, otherAisle)
+	}
+	for i := 1; i < len(aisles)-1; i++ {
+		if aisles[i-1] > aisles[i] {
+			t.Errorf("aisles = %q, want them sorted", aisles)
+		}
+	}
+}
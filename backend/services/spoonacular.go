@@ -1,22 +1,30 @@
 package services
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
-	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 )
 
 const (
-	BaseURL = "https://api.spoonacular.com/recipes"
+	// DefaultBaseURL is the Spoonacular API root used when SPOONACULAR_BASE_URL is not set
+	DefaultBaseURL = "https://api.spoonacular.com"
 )
 
+// getSpoonacularBaseURL returns the API root from environment variables
+func getSpoonacularBaseURL() string {
+	if baseURL := os.Getenv("SPOONACULAR_BASE_URL"); baseURL != "" {
+		return strings.TrimRight(baseURL, "/")
+	}
+	return DefaultBaseURL
+}
+
 // getSpoonacularAPIKey returns the API key from environment variables
 func getSpoonacularAPIKey() string {
 	apiKey := os.Getenv("SPOONACULAR_API_KEY")
@@ -47,20 +55,51 @@ func getAPITimeout() time.Duration {
 	return 30 * time.Second // Default to 30 seconds
 }
 
+// GetRequestTimeout returns how long a request may wait on Spoonacular in total, retries
+// included, from environment variables
+func GetRequestTimeout() time.Duration {
+	if seconds := os.Getenv("REQUEST_TIMEOUT_SECONDS"); seconds != "" {
+		if s, err := strconv.Atoi(seconds); err == nil && s > 0 {
+			return time.Duration(s) * time.Second
+		}
+	}
+	return 30 * time.Second // Default to 30 seconds
+}
+
+// RecipeProvider is a source of recipes and ingredients that the HTTP
+// handlers can query. SpoonacularService is the default implementation.
+// Every method gives up when ctx is cancelled or its deadline passes. Results are the
+// caller's own copies, so their slices and fields may be changed; slices nested inside
+// them, like a recipe's ingredients, may be shared with a cache and must be replaced
+// rather than changed in place.
+type RecipeProvider interface {
+	// SearchRecipesByIngredientsContext returns recipes that use the given ingredients
+	SearchRecipesByIngredientsContext(ctx context.Context, ingredients []string) ([]Recipe, error)
+	// SearchRecipesPageContext returns one sorted page of the recipes that use the given
+	// ingredients, or of popular recipes when none are given
+	SearchRecipesPageContext(ctx context.Context, options RecipeSearchOptions) (*RecipePage, error)
+	// SearchIngredientsContext returns ingredients whose name matches the query
+	SearchIngredientsContext(ctx context.Context, query string) ([]Ingredient, error)
+	// GetRecipeDetailsContext returns the full details of a single recipe, or an error
+	// matching ErrRecipeNotFound if the provider has no such recipe
+	GetRecipeDetailsContext(ctx context.Context, recipeID RecipeID) (*RecipeDetails, error)
+	// GetPopularRecipesContext returns recipes to show when no ingredients are given
+	GetPopularRecipesContext(ctx context.Context) ([]Recipe, error)
+}
+
+// Ensure SpoonacularService satisfies RecipeProvider
+var _ RecipeProvider = (*SpoonacularService)(nil)
+
 // SpoonacularService handles interactions with the Spoonacular API
 type SpoonacularService struct {
-	client    *http.Client
-	cache     map[string]*CacheEntry
-	cacheMux  sync.RWMutex
+	upstream  *upstreamClient
+	baseURL   string
+	cache     Cache
+	flights   flightGroup
+	quota     *QuotaTracker
 	storage   *StorageService
 }
 
-// CacheEntry represents a cached API response
-type CacheEntry struct {
-	Data      interface{}
-	ExpiresAt time.Time
-}
-
 // SpoonacularRecipe represents the recipe structure from Spoonacular API
 type SpoonacularRecipe struct {
 	ID                     int                    `json:"id"`
@@ -141,6 +180,18 @@ type Recipe struct {
 	Servings    int      `json:"servings"`
 	ImageURL    string   `json:"imageUrl"`
 	MatchCount  int      `json:"matchCount"`
+	MatchScore  float64  `json:"matchScore,omitempty"` // How much of the recipe the user's ingredients cover, 0 to 1
+	Matches     []IngredientMatch `json:"matches,omitempty"` // How each recipe ingredient was matched
+	MissingCount int     `json:"missingCount"` // Recipe ingredients the user doesn't have
+	UsesExpiring []string `json:"usesExpiring,omitempty"` // Pantry items close to expiry the recipe uses up
+	ExpiryScore  float64  `json:"expiryScore,omitempty"`  // How urgently those items need using, summed
+	UsedIngredients   []DetailedIngredient `json:"usedIngredients"`   // Recipe ingredients the user has
+	MissedIngredients []DetailedIngredient `json:"missedIngredients"` // What the user still needs, with amounts
+	UnusedIngredients []string             `json:"unusedIngredients"` // The user's ingredients the recipe doesn't use
+	ReadyInMinutes  int     `json:"readyInMinutes,omitempty"`
+	HealthScore     float64 `json:"healthScore,omitempty"`
+	PricePerServing float64 `json:"pricePerServing,omitempty"` // In US cents
+	Stale       bool     `json:"stale,omitempty"` // Served from storage past its soft TTL
 }
 
 // SpoonacularIngredientSearch represents ingredient search results from Spoonacular
@@ -160,69 +211,130 @@ type Ingredient struct {
 	ID    int    `json:"id"`
 	Name  string `json:"name"`
 	Image string `json:"image"`
+	Stale bool   `json:"stale,omitempty"` // Served from storage past its soft TTL
 }
 
-// NewSpoonacularService creates a new Spoonacular service
-func NewSpoonacularService() *SpoonacularService {
+// NewSpoonacularService creates a new Spoonacular service backed by the given storage
+func NewSpoonacularService(storage *StorageService) *SpoonacularService {
+	quota := NewQuotaTracker(storage, getDailyPointBudget())
+	client := &http.Client{
+		Timeout: getAPITimeout(),
+	}
+
 	return &SpoonacularService{
-		client: &http.Client{
-			Timeout: getAPITimeout(),
-		},
-		cache:   make(map[string]*CacheEntry),
-		storage: NewStorageService(),
-	}
-}
-
-// SearchRecipesByIngredients searches for recipes using the provided ingredients
-func (s *SpoonacularService) SearchRecipesByIngredients(ingredients []string) ([]Recipe, error) {
-	// Create search query string for storage
-	searchQuery := strings.Join(ingredients, ",")
-	
-	// Create cache key
-	cacheKey := fmt.Sprintf("search_%s", searchQuery)
-	
-	// Check memory cache first (fastest)
-	if cachedData := s.getFromCache(cacheKey); cachedData != nil {
-		if recipes, ok := cachedData.([]Recipe); ok {
-			fmt.Printf("⚡ Using memory cache for ingredients: %v\n", ingredients)
-			return recipes, nil
-		}
+		upstream: newUpstreamClient(client, quota),
+		baseURL:  getSpoonacularBaseURL(),
+		cache:    NewLRUCacheFromEnv(),
+		storage:  storage,
+		quota:    quota,
 	}
+}
 
-	// Check persistent storage (still faster than API call)
-	if storedRecipes, err := s.storage.LoadRecipes(searchQuery); err == nil && storedRecipes != nil {
-		// Also cache in memory for faster subsequent access
-		s.setCache(cacheKey, storedRecipes)
-		return storedRecipes, nil
+// SetCache replaces the in-memory cache, for example with one shared between instances.
+// The previous cache is closed.
+func (s *SpoonacularService) SetCache(cache Cache) {
+	previous := s.cache
+	s.cache = cache
+	if previous != nil {
+		previous.Close()
 	}
+}
+
+// CacheStats returns the size and hit/miss/eviction counters of the in-memory cache
+func (s *SpoonacularService) CacheStats() CacheStats {
+	return s.cache.Stats()
+}
+
+// Close stops the cache's background work
+func (s *SpoonacularService) Close() {
+	s.cache.Close()
+}
+
+// QuotaStatus returns today's Spoonacular point usage and whether the daily budget is spent
+func (s *SpoonacularService) QuotaStatus() QuotaStatus {
+	return s.quota.Status()
+}
+
+// SetBaseURL points the service at a different API root, such as a local stand-in server
+func (s *SpoonacularService) SetBaseURL(baseURL string) {
+	s.baseURL = strings.TrimRight(baseURL, "/")
+}
+
+// SearchRecipesByIngredientsContext searches for recipes using the provided ingredients,
+// giving up when ctx ends
+func (s *SpoonacularService) SearchRecipesByIngredientsContext(ctx context.Context, ingredients []string) ([]Recipe, error) {
+	// Equivalent ingredient lists share one canonical query for every cache and request
+	query := NewIngredientQuery(ingredients)
 
 	// If no ingredients provided, return popular recipes
-	if len(ingredients) == 0 {
-		return s.getPopularRecipes()
+	if query.IsEmpty() {
+		return s.GetPopularRecipesContext(ctx)
 	}
 
-	// Build API URL
-	ingredientsStr := strings.Join(ingredients, ",")
-	apiURL := fmt.Sprintf("%s/findByIngredients?apiKey=%s&ingredients=%s&number=12&ranking=1&ignorePantry=true",
-		BaseURL, getSpoonacularAPIKey(), url.QueryEscape(ingredientsStr))
+	return s.searchPool(ctx, query)
+}
 
-	fmt.Printf("🌐 Making Spoonacular API call for ingredients: %v\n", ingredients)
+// searchPool returns the recipes Spoonacular ranks for a query, as many as its pool size
+func (s *SpoonacularService) searchPool(ctx context.Context, query IngredientQuery) ([]Recipe, error) {
+	if query.IsEmpty() {
+		return s.lookupRecipes(ctx, query, "popular recipes", func(ctx context.Context) ([]Recipe, error) {
+			return s.fetchPopularRecipes(ctx, query)
+		})
+	}
+	return s.lookupRecipes(ctx, query, "ingredients: "+query.String(), func(ctx context.Context) ([]Recipe, error) {
+		return s.fetchRecipesByIngredients(ctx, query)
+	})
+}
 
-	// Make API request
-	resp, err := s.client.Get(apiURL)
+// lookupRecipes serves the recipes of a query through the memory cache, storage and API
+func (s *SpoonacularService) lookupRecipes(ctx context.Context, query IngredientQuery, description string, fetch func(ctx context.Context) ([]Recipe, error)) ([]Recipe, error) {
+	result, _, err := s.lookup(ctx, cachedLookup{
+		description: description,
+		cacheKey:    query.CacheKey(),
+		load: func() (interface{}, time.Time, error) {
+			recipes, storedAt, err := s.storage.LoadRecipes(query)
+			if recipes == nil {
+				return nil, storedAt, err
+			}
+			return recipes, storedAt, err
+		},
+		fetch: func(ctx context.Context) (interface{}, error) {
+			return fetch(ctx)
+		},
+		markStale: func(value interface{}) interface{} {
+			recipes := append([]Recipe(nil), value.([]Recipe)...)
+			for i := range recipes {
+				recipes[i].Stale = true
+			}
+			return recipes
+		},
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to make API request: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
-	}
+	// The result may be the cached slice; callers adjust match counts, so each gets a copy
+	cached := result.([]Recipe)
+	recipes := make([]Recipe, len(cached))
+	copy(recipes, cached)
+	return recipes, nil
+}
+
+// fetchRecipesByIngredients calls the API for a recipe search and stores the results
+func (s *SpoonacularService) fetchRecipesByIngredients(ctx context.Context, query IngredientQuery) ([]Recipe, error) {
+	ingredients := query.Ingredients()
 
-	// Parse response
+	// Build API URL
+	ingredientsStr := query.String()
+	apiURL := fmt.Sprintf("%s/recipes/findByIngredients?apiKey=%s&ingredients=%s&number=%d&ranking=%d&ignorePantry=%t",
+		s.baseURL, getSpoonacularAPIKey(), url.QueryEscape(ingredientsStr), query.PoolSize(), query.Ranking(), !query.IncludesStaples())
+
+	fmt.Printf("🌐 Making Spoonacular API call for ingredients: %v\n", ingredients)
+
+	// Make API request
 	var spoonacularRecipes []SpoonacularRecipe
-	if err := json.NewDecoder(resp.Body).Decode(&spoonacularRecipes); err != nil {
-		return nil, fmt.Errorf("failed to decode API response: %v", err)
+	if err := s.upstream.getJSON(ctx, "findByIngredients", apiURL, &spoonacularRecipes); err != nil {
+		return nil, fmt.Errorf("failed to search recipes: %w", err)
 	}
 
 	// Convert to our recipe format
@@ -232,66 +344,38 @@ func (s *SpoonacularService) SearchRecipesByIngredients(ingredients []string) ([
 		recipes = append(recipes, recipe)
 	}
 
-	// Sort by match count (highest first)
-	sort.Slice(recipes, func(i, j int) bool {
-		return recipes[i].MatchCount > recipes[j].MatchCount
-	})
-
+	// Keep Spoonacular's order, which follows the query's ranking mode
 	// Save to persistent storage
-	if err := s.storage.SaveRecipes(recipes, searchQuery); err != nil {
+	if err := s.storage.SaveRecipes(recipes, query); err != nil {
 		fmt.Printf("⚠️  Warning: Could not save recipes to storage: %v\n", err)
 	}
 
 	// Cache the results in memory
-	s.setCache(cacheKey, recipes)
+	s.setCache(query.CacheKey(), recipes)
 
 	fmt.Printf("✅ Found %d recipes from Spoonacular API\n", len(recipes))
 	return recipes, nil
 }
 
-// getPopularRecipes gets popular recipes when no ingredients are specified
-func (s *SpoonacularService) getPopularRecipes() ([]Recipe, error) {
-	cacheKey := "popular_recipes"
-	searchQuery := "" // Empty string for popular recipes
-	
-	// Check memory cache first
-	if cachedData := s.getFromCache(cacheKey); cachedData != nil {
-		if recipes, ok := cachedData.([]Recipe); ok {
-			fmt.Printf("⚡ Using memory cache for popular recipes\n")
-			return recipes, nil
-		}
-	}
-
-	// Check persistent storage
-	if storedRecipes, err := s.storage.LoadRecipes(searchQuery); err == nil && storedRecipes != nil {
-		// Also cache in memory for faster subsequent access
-		s.setCache(cacheKey, storedRecipes)
-		return storedRecipes, nil
-	}
+// GetPopularRecipesContext gets popular recipes, giving up when ctx ends
+func (s *SpoonacularService) GetPopularRecipesContext(ctx context.Context) ([]Recipe, error) {
+	return s.searchPool(ctx, NewIngredientQuery(nil)) // The empty query stands for popular recipes
+}
 
+// fetchPopularRecipes calls the API for random popular recipes and stores the results
+func (s *SpoonacularService) fetchPopularRecipes(ctx context.Context, query IngredientQuery) ([]Recipe, error) {
 	// Build API URL for popular recipes
-	apiURL := fmt.Sprintf("%s/random?apiKey=%s&number=12",
-		BaseURL, getSpoonacularAPIKey())
+	apiURL := fmt.Sprintf("%s/recipes/random?apiKey=%s&number=%d",
+		s.baseURL, getSpoonacularAPIKey(), query.PoolSize())
 
 	fmt.Printf("🌐 Making Spoonacular API call for popular recipes\n")
 
 	// Make API request
-	resp, err := s.client.Get(apiURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make API request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
-	}
-
-	// Parse response
 	var randomResponse struct {
 		Recipes []SpoonacularRecipeInfo `json:"recipes"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&randomResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode API response: %v", err)
+	if err := s.upstream.getJSON(ctx, "random", apiURL, &randomResponse); err != nil {
+		return nil, fmt.Errorf("failed to get popular recipes: %w", err)
 	}
 
 	// Convert to our recipe format
@@ -302,12 +386,12 @@ func (s *SpoonacularService) getPopularRecipes() ([]Recipe, error) {
 	}
 
 	// Save to persistent storage
-	if err := s.storage.SaveRecipes(recipes, searchQuery); err != nil {
+	if err := s.storage.SaveRecipes(recipes, query); err != nil {
 		fmt.Printf("⚠️  Warning: Could not save popular recipes to storage: %v\n", err)
 	}
 
 	// Cache the results in memory
-	s.setCache(cacheKey, recipes)
+	s.setCache(query.CacheKey(), recipes)
 
 	fmt.Printf("✅ Found %d popular recipes from Spoonacular API\n", len(recipes))
 	return recipes, nil
@@ -315,13 +399,21 @@ func (s *SpoonacularService) getPopularRecipes() ([]Recipe, error) {
 
 // convertSpoonacularRecipe converts a Spoonacular recipe to our internal format
 func (s *SpoonacularService) convertSpoonacularRecipe(sr SpoonacularRecipe, userIngredients []string) Recipe {
-	// Extract ingredients
+	// Extract ingredients, keeping which of them the user has
 	ingredients := make([]string, 0)
+	usedIngredients := make([]DetailedIngredient, 0, len(sr.UsedIngredients))
 	for _, ing := range sr.UsedIngredients {
 		ingredients = append(ingredients, ing.Name)
+		usedIngredients = append(usedIngredients, convertSpoonacularIngredient(ing))
 	}
+	missedIngredients := make([]DetailedIngredient, 0, len(sr.MissedIngredients))
 	for _, ing := range sr.MissedIngredients {
 		ingredients = append(ingredients, ing.Name)
+		missedIngredients = append(missedIngredients, convertSpoonacularIngredient(ing))
+	}
+	unusedIngredients := make([]string, 0, len(sr.UnusedIngredients))
+	for _, ing := range sr.UnusedIngredients {
+		unusedIngredients = append(unusedIngredients, ing.Name)
 	}
 
 	// Remove HTML tags from title if any
@@ -338,15 +430,21 @@ func (s *SpoonacularService) convertSpoonacularRecipe(sr SpoonacularRecipe, user
 		Servings:    4,        // Default since not provided in search API
 		ImageURL:    sr.Image,
 		MatchCount:  sr.UsedIngredientCount,
+		MissingCount: sr.MissedIngredientCount,
+		UsedIngredients:   usedIngredients,
+		MissedIngredients: missedIngredients,
+		UnusedIngredients: unusedIngredients,
 	}
 }
 
 // convertSpoonacularRecipeInfo converts detailed recipe info to our internal format
 func (s *SpoonacularService) convertSpoonacularRecipeInfo(sr SpoonacularRecipeInfo) Recipe {
-	// Extract ingredients
+	// Extract ingredients; without user ingredients every one of them is missing
 	ingredients := make([]string, 0, len(sr.ExtendedIngredients))
+	missedIngredients := make([]DetailedIngredient, 0, len(sr.ExtendedIngredients))
 	for _, ing := range sr.ExtendedIngredients {
 		ingredients = append(ingredients, ing.Name)
+		missedIngredients = append(missedIngredients, convertSpoonacularIngredient(ing))
 	}
 
 	// Format cooking times
@@ -384,92 +482,79 @@ func (s *SpoonacularService) convertSpoonacularRecipeInfo(sr SpoonacularRecipeIn
 		Servings:    sr.Servings,
 		ImageURL:    sr.Image,
 		MatchCount:  0, // Will be calculated based on user ingredients
+		MissingCount: len(ingredients),
+		UsedIngredients:   []DetailedIngredient{},
+		MissedIngredients: missedIngredients,
+		UnusedIngredients: []string{},
+		ReadyInMinutes:  sr.ReadyInMinutes,
+		HealthScore:     sr.HealthScore,
+		PricePerServing: sr.PricePerServing,
 	}
 }
 
 // getFromCache retrieves data from cache if it exists and is not expired
 func (s *SpoonacularService) getFromCache(key string) interface{} {
-	s.cacheMux.RLock()
-	defer s.cacheMux.RUnlock()
-
-	entry, exists := s.cache[key]
-	if !exists || time.Now().After(entry.ExpiresAt) {
+	data, exists := s.cache.Get(key)
+	if !exists {
 		return nil
 	}
-
-	return entry.Data
+	return data
 }
 
 // setCache stores data in cache with expiration
 func (s *SpoonacularService) setCache(key string, data interface{}) {
-	s.cacheMux.Lock()
-	defer s.cacheMux.Unlock()
-
-	s.cache[key] = &CacheEntry{
-		Data:      data,
-		ExpiresAt: time.Now().Add(getCacheDuration()),
-	}
-}
-
-// CalculateMatchCount calculates how many user ingredients match recipe ingredients
-func (s *SpoonacularService) CalculateMatchCount(userIngredients []string, recipeIngredients []string) int {
-	count := 0
-	for _, userIng := range userIngredients {
-		for _, recipeIng := range recipeIngredients {
-			if strings.EqualFold(strings.TrimSpace(userIng), strings.TrimSpace(recipeIng)) {
-				count++
-				break
-			}
-		}
-	}
-	return count
+	s.cache.Set(key, data, getCacheDuration())
 }
 
-// SearchIngredients searches for ingredients by name with caching and persistence
-func (s *SpoonacularService) SearchIngredients(query string) ([]Ingredient, error) {
+// SearchIngredientsContext searches for ingredients by name, giving up when ctx ends
+func (s *SpoonacularService) SearchIngredientsContext(ctx context.Context, query string) ([]Ingredient, error) {
 	if query == "" {
 		return []Ingredient{}, nil
 	}
 
-	// Create cache key
-	cacheKey := fmt.Sprintf("ingredients_%s", strings.ToLower(query))
-	
-	// Check memory cache first (fastest)
-	if cachedData := s.getFromCache(cacheKey); cachedData != nil {
-		if ingredients, ok := cachedData.([]Ingredient); ok {
-			fmt.Printf("⚡ Using memory cache for ingredient search: %s\n", query)
-			return ingredients, nil
-		}
-	}
-
-	// Check persistent storage (still faster than API call)
-	if storedIngredients, err := s.storage.LoadIngredients(query); err == nil && storedIngredients != nil {
-		// Also cache in memory for faster subsequent access
-		s.setCache(cacheKey, storedIngredients)
-		return storedIngredients, nil
+	cacheKey := fmt.Sprintf("ingredients_%s", strings.ToLower(strings.TrimSpace(query)))
+	result, _, err := s.lookup(ctx, cachedLookup{
+		description: "ingredient search: " + query,
+		cacheKey:    cacheKey,
+		load: func() (interface{}, time.Time, error) {
+			ingredients, storedAt, err := s.storage.LoadIngredients(query)
+			if ingredients == nil {
+				return nil, storedAt, err
+			}
+			return ingredients, storedAt, err
+		},
+		fetch: func(ctx context.Context) (interface{}, error) {
+			return s.fetchIngredients(ctx, query, cacheKey)
+		},
+		markStale: func(value interface{}) interface{} {
+			ingredients := append([]Ingredient(nil), value.([]Ingredient)...)
+			for i := range ingredients {
+				ingredients[i].Stale = true
+			}
+			return ingredients
+		},
+	})
+	if err != nil {
+		return nil, err
 	}
+	cached := result.([]Ingredient)
+	ingredients := make([]Ingredient, len(cached))
+	copy(ingredients, cached)
+	return ingredients, nil
+}
 
+// fetchIngredients calls the API for an ingredient search and stores the results
+func (s *SpoonacularService) fetchIngredients(ctx context.Context, query, cacheKey string) ([]Ingredient, error) {
 	// Build API URL for ingredient search
-	apiURL := fmt.Sprintf("https://api.spoonacular.com/food/ingredients/search?apiKey=%s&query=%s&number=10&metaInformation=false",
-		getSpoonacularAPIKey(), url.QueryEscape(query))
+	apiURL := fmt.Sprintf("%s/food/ingredients/search?apiKey=%s&query=%s&number=10&metaInformation=false",
+		s.baseURL, getSpoonacularAPIKey(), url.QueryEscape(query))
 
 	fmt.Printf("🌐 Making Spoonacular API call for ingredient search: %s\n", query)
 
 	// Make API request
-	resp, err := s.client.Get(apiURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make ingredient search API request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("ingredient search API request failed with status: %d", resp.StatusCode)
-	}
-
-	// Parse response
 	var searchResponse SpoonacularIngredientSearch
-	if err := json.NewDecoder(resp.Body).Decode(&searchResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode ingredient search API response: %v", err)
+	if err := s.upstream.getJSON(ctx, "ingredientSearch", apiURL, &searchResponse); err != nil {
+		return nil, fmt.Errorf("failed to search ingredients: %w", err)
 	}
 
 	// Convert to our ingredient format
@@ -495,47 +580,59 @@ func (s *SpoonacularService) SearchIngredients(query string) ([]Ingredient, erro
 	return ingredients, nil
 }
 
-// GetRecipeDetails fetches detailed recipe information by ID
-func (s *SpoonacularService) GetRecipeDetails(recipeID string) (*RecipeDetails, error) {
-	// Create cache key
-	cacheKey := fmt.Sprintf("recipe_details_%s", recipeID)
-	
-	// Check memory cache first (fastest)
-	if cachedData := s.getFromCache(cacheKey); cachedData != nil {
-		if recipeDetails, ok := cachedData.(*RecipeDetails); ok {
-			fmt.Printf("⚡ Using memory cache for recipe details: %s\n", recipeID)
-			return recipeDetails, nil
-		}
+// GetRecipeDetailsContext fetches detailed recipe information by ID, giving up when ctx ends
+func (s *SpoonacularService) GetRecipeDetailsContext(ctx context.Context, id RecipeID) (*RecipeDetails, error) {
+	if id.Source() != SourceSpoonacular {
+		return nil, fmt.Errorf("%w: %s is not a Spoonacular recipe", ErrRecipeNotFound, id)
 	}
 
-	// Check persistent storage (still faster than API call)
-	if storedRecipe, err := s.storage.LoadRecipeDetails(recipeID); err == nil && storedRecipe != nil {
-		// Also cache in memory for faster subsequent access
-		s.setCache(cacheKey, storedRecipe)
-		return storedRecipe, nil
+	// The validated number is the only part of the ID that reaches URLs and storage keys
+	recipeID := id.Value()
+	cacheKey := fmt.Sprintf("recipe_details_%s", recipeID)
+	result, _, err := s.lookup(ctx, cachedLookup{
+		description: "recipe details: " + recipeID,
+		cacheKey:    cacheKey,
+		load: func() (interface{}, time.Time, error) {
+			recipeDetails, storedAt, err := s.storage.LoadRecipeDetails(recipeID)
+			if recipeDetails == nil {
+				return nil, storedAt, err
+			}
+			return recipeDetails, storedAt, err
+		},
+		fetch: func(ctx context.Context) (interface{}, error) {
+			return s.fetchRecipeDetails(ctx, recipeID, cacheKey)
+		},
+		markStale: func(value interface{}) interface{} {
+			recipeDetails := *value.(*RecipeDetails)
+			recipeDetails.Stale = true
+			return &recipeDetails
+		},
+	})
+	if err != nil {
+		if errors.Is(err, ErrUpstreamNotFound) {
+			return nil, fmt.Errorf("%w: %v", ErrRecipeNotFound, err)
+		}
+		return nil, err
 	}
+	// The result may be the cached details; callers get a copy with their own lists
+	recipeDetails := *result.(*RecipeDetails)
+	recipeDetails.Ingredients = append([]DetailedIngredient(nil), recipeDetails.Ingredients...)
+	recipeDetails.Instructions = append([]Instruction(nil), recipeDetails.Instructions...)
+	return &recipeDetails, nil
+}
 
+// fetchRecipeDetails calls the API for a recipe's details and stores them
+func (s *SpoonacularService) fetchRecipeDetails(ctx context.Context, recipeID, cacheKey string) (*RecipeDetails, error) {
 	// Build API URL for detailed recipe information
-	apiURL := fmt.Sprintf("%s/%s/information?apiKey=%s&includeNutrition=false",
-		BaseURL, recipeID, getSpoonacularAPIKey())
+	apiURL := fmt.Sprintf("%s/recipes/%s/information?apiKey=%s&includeNutrition=false",
+		s.baseURL, recipeID, getSpoonacularAPIKey())
 
 	fmt.Printf("🌐 Making Spoonacular API call for recipe details: %s\n", recipeID)
 
 	// Make API request
-	resp, err := s.client.Get(apiURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make recipe details API request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("recipe details API request failed with status: %d", resp.StatusCode)
-	}
-
-	// Parse response
 	var recipeInfo SpoonacularRecipeInfo
-	if err := json.NewDecoder(resp.Body).Decode(&recipeInfo); err != nil {
-		return nil, fmt.Errorf("failed to decode recipe details API response: %v", err)
+	if err := s.upstream.getJSON(ctx, "information", apiURL, &recipeInfo); err != nil {
+		return nil, fmt.Errorf("failed to get recipe details: %w", err)
 	}
 
 	// Convert to our detailed recipe format
@@ -564,7 +661,10 @@ type RecipeDetails struct {
 	PrepTime             string                `json:"prepTime"`
 	CookTime             string                `json:"cookTime"`
 	TotalTime            string                `json:"totalTime"`
+	ReadyInMinutes       int                   `json:"readyInMinutes,omitempty"` // 0 when unknown, though TotalTime then shows a default
 	Servings             int                   `json:"servings"`
+	ScaledFrom           int                   `json:"scaledFrom,omitempty"` // The recipe's own servings when scaled to Servings
+	Units                string                `json:"units,omitempty"`      // The measurement system amounts were converted to
 	ImageURL             string                `json:"imageUrl"`
 	SourceURL            string                `json:"sourceUrl"`
 	SpoonacularURL       string                `json:"spoonacularUrl"`
@@ -582,6 +682,7 @@ type RecipeDetails struct {
 	IsCheap              bool                  `json:"isCheap"`
 	IsPopular            bool                  `json:"isPopular"`
 	IsSustainable        bool                  `json:"isSustainable"`
+	Stale                bool                  `json:"stale,omitempty"` // Served from storage past its soft TTL
 }
 
 // DetailedIngredient represents a detailed ingredient with measurements
@@ -590,6 +691,7 @@ type DetailedIngredient struct {
 	Name         string   `json:"name"`
 	OriginalName string   `json:"originalName"`
 	Amount       float64  `json:"amount"`
+	AmountMax    float64  `json:"amountMax,omitempty"` // Upper bound when the amount is a range, e.g. 2-3
 	Unit         string   `json:"unit"`
 	UnitLong     string   `json:"unitLong"`
 	Original     string   `json:"original"`
@@ -604,24 +706,31 @@ type Instruction struct {
 	Step   string `json:"step"`
 }
 
+// convertSpoonacularIngredient converts a Spoonacular ingredient with its measurements
+func convertSpoonacularIngredient(ing SpoonacularIngredient) DetailedIngredient {
+	ingredient := DetailedIngredient{
+		ID:           ing.ID,
+		Name:         ing.Name,
+		OriginalName: ing.OriginalName,
+		Amount:       ing.Amount,
+		Unit:         ing.Unit,
+		UnitLong:     ing.UnitLong,
+		Original:     ing.Original,
+		Aisle:        ing.Aisle,
+		Image:        fmt.Sprintf("https://spoonacular.com/cdn/ingredients_100x100/%s", ing.Image),
+		Meta:         ing.Meta,
+	}
+	// Some Spoonacular data lacks a parsed amount or name; the original line still has them
+	fillIngredientGaps(&ingredient)
+	return ingredient
+}
+
 // convertToRecipeDetails converts SpoonacularRecipeInfo to our detailed format
 func (s *SpoonacularService) convertToRecipeDetails(sr SpoonacularRecipeInfo) *RecipeDetails {
 	// Extract detailed ingredients
 	ingredients := make([]DetailedIngredient, 0, len(sr.ExtendedIngredients))
 	for _, ing := range sr.ExtendedIngredients {
-		ingredient := DetailedIngredient{
-			ID:           ing.ID,
-			Name:         ing.Name,
-			OriginalName: ing.OriginalName,
-			Amount:       ing.Amount,
-			Unit:         ing.Unit,
-			UnitLong:     ing.UnitLong,
-			Original:     ing.Original,
-			Aisle:        ing.Aisle,
-			Image:        fmt.Sprintf("https://spoonacular.com/cdn/ingredients_100x100/%s", ing.Image),
-			Meta:         ing.Meta,
-		}
-		ingredients = append(ingredients, ingredient)
+		ingredients = append(ingredients, convertSpoonacularIngredient(ing))
 	}
 
 	// Extract instructions
@@ -707,6 +816,7 @@ func (s *SpoonacularService) convertToRecipeDetails(sr SpoonacularRecipeInfo) *R
 		PrepTime:          prepTime,
 		CookTime:          cookTime,
 		TotalTime:         totalTime,
+		ReadyInMinutes:    sr.ReadyInMinutes,
 		Servings:          sr.Servings,
 		ImageURL:          sr.Image,
 		SourceURL:         sr.SourceUrl,
@@ -4,29 +4,35 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"sync"
 	"time"
 )
 
-// StorageService handles persistent storage of recipes
+// popularRecipesKey is the record key of the popular recipes shown without ingredients
+const popularRecipesKey = "popular"
+
+// StorageService handles persistent storage of recipes on top of a pluggable Store
 type StorageService struct {
-	dataDir   string
-	fileMutex sync.RWMutex
+	dataDir       string
+	store         Store
+	index         *SearchIndex
+	localRecipeMu sync.Mutex // Serializes every change to local recipes, which read and rewrite them
+	pantryMu      sync.Mutex // Serializes every change to pantry items, which read and rewrite them
 }
 
 // StoredRecipe represents a recipe with metadata for storage
 type StoredRecipe struct {
 	Recipe
-	StoredAt         time.Time `json:"storedAt"`
-	SearchQuery      string    `json:"searchQuery"`      // The original ingredients used to find this recipe
-	NormalizedQuery  string    `json:"normalizedQuery"`  // The normalized/sorted ingredients
-	Source           string    `json:"source"`           // "spoonacular"
-	Filename         string    `json:"filename"`         // The hash-based filename for reference
+	StoredAt        time.Time `json:"storedAt"`
+	SearchQuery     string    `json:"searchQuery"`     // The original ingredients used to find this recipe
+	NormalizedQuery string    `json:"normalizedQuery"` // The normalized/sorted ingredients
+	Source          string    `json:"source"`          // "spoonacular"
+	Filename        string    `json:"filename"`        // The record filename for reference
 }
 
 // StoredIngredient represents an ingredient with metadata for storage
@@ -35,47 +41,158 @@ type StoredIngredient struct {
 	StoredAt    time.Time `json:"storedAt"`
 	SearchQuery string    `json:"searchQuery"` // The query used to find this ingredient
 	Source      string    `json:"source"`      // "spoonacular"
-	Filename    string    `json:"filename"`    // The hash-based filename for reference
+	Filename    string    `json:"filename"`    // The record filename for reference
 }
 
 // IngredientStorage represents the storage structure for ingredients
 type IngredientStorage struct {
-	Ingredients []StoredIngredient `json:"ingredients"`
-	LastUpdated time.Time          `json:"lastUpdated"`
-	SearchQuery string             `json:"searchQuery"`
+	SchemaVersion int                `json:"schemaVersion"`
+	Ingredients   []StoredIngredient `json:"ingredients"`
+	LastUpdated   time.Time          `json:"lastUpdated"`
+	SearchQuery   string             `json:"searchQuery"`
 }
 
 // RecipeStorage represents the structure of our JSON storage files
 type RecipeStorage struct {
-	LastUpdated time.Time      `json:"lastUpdated"`
-	Recipes     []StoredRecipe `json:"recipes"`
+	SchemaVersion int            `json:"schemaVersion"`
+	LastUpdated   time.Time      `json:"lastUpdated"`
+	Recipes       []StoredRecipe `json:"recipes"`
 }
 
+// NewStorageService creates a new storage service using the backend selected by
+// STORAGE_BACKEND. It fails rather than fall back to another backend, which would
+// split the user's data between two stores.
+func NewStorageService() (*StorageService, error) {
+	store, err := NewStoreFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s storage: %v", getStorageBackend(), err)
+	}
+	return NewStorageServiceWithStore(store), nil
+}
 
+// NewJSONStorageService creates a storage service on JSON files in dataDir
+func NewJSONStorageService(dataDir string) (*StorageService, error) {
+	store, err := NewJSONStore(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	return NewStorageServiceWithStore(store), nil
+}
 
-// NewStorageService creates a new storage service
-func NewStorageService() *StorageService {
-	dataDir := "data"
-	
-	// Create data directory if it doesn't exist
-	if err := os.MkdirAll(dataDir, 0755); err != nil {
-		fmt.Printf("⚠️  Warning: Could not create data directory: %v\n", err)
+// NewStorageServiceWithStore creates a storage service on top of the given store
+func NewStorageServiceWithStore(store Store) *StorageService {
+	s := &StorageService{
+		dataDir: store.Dir(),
+		store:   store,
+		index:   NewSearchIndex(),
 	}
 
-	return &StorageService{
-		dataDir: dataDir,
+	fmt.Printf("🗄️  Using %s storage\n", store.Name())
+
+	// Build the search index from everything already stored
+	s.rebuildIndex()
+
+	return s
+}
+
+// Close releases the underlying store
+func (s *StorageService) Close() error {
+	return s.store.Close()
+}
+
+// Index returns the full-text index over every stored recipe
+func (s *StorageService) Index() *SearchIndex {
+	return s.index
+}
+
+// rebuildIndex re-reads every stored recipe into the search index
+func (s *StorageService) rebuildIndex() {
+	s.index.Reset()
+
+	if records, err := s.store.List(KindRecipeDetails); err == nil {
+		for _, record := range records {
+			var storage RecipeDetailsStorage
+			if err := s.decodeRecord(record, &storage); err == nil && storage.RecipeDetails != nil {
+				s.index.Add(searchResultFromDetails(storage.RecipeDetails), true)
+			}
+		}
 	}
+
+	if records, err := s.store.List(KindLocalRecipe); err == nil {
+		for _, record := range records {
+			var storage LocalRecipeStorage
+			if err := s.decodeRecord(record, &storage); err == nil && storage.Recipe != nil {
+				s.index.Add(searchResultFromLocal(storage.Recipe), true)
+			}
+		}
+	}
+
+	if records, err := s.store.List(KindRecipes); err == nil {
+		for _, record := range records {
+			var storage RecipeStorage
+			if err := s.decodeRecord(record, &storage); err == nil {
+				for _, stored := range storage.Recipes {
+					s.index.Add(searchResultFromSummary(stored.Recipe), false)
+				}
+			}
+		}
+	}
+
+	fmt.Printf("🔎 Indexed %d stored recipes\n", s.index.Len())
+}
+
+// putJSON marshals a value and stores it as a record
+func (s *StorageService) putJSON(kind RecordKind, key string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %v", kind, err)
+	}
+	return s.store.Put(Record{
+		Kind:      kind,
+		Key:       key,
+		Data:      data,
+		UpdatedAt: time.Now(),
+	})
+}
+
+// getJSON loads a record into value, returning ErrRecordNotFound if it does not exist
+func (s *StorageService) getJSON(kind RecordKind, key string, value interface{}) error {
+	record, err := s.store.Get(kind, key)
+	if err != nil {
+		return err
+	}
+	return s.decodeRecord(*record, value)
 }
 
-// SaveRecipes saves recipes to persistent storage
-func (s *StorageService) SaveRecipes(recipes []Recipe, searchQuery string) error {
-	s.fileMutex.Lock()
-	defer s.fileMutex.Unlock()
+// decodeRecord upgrades a record to the current schema version of its kind, writing the
+// upgraded document back to the store, and decodes it into value
+func (s *StorageService) decodeRecord(record Record, value interface{}) error {
+	filename := recordFilename(record.Kind, record.Key)
 
-	// Create filename based on search query
-	filename := s.getFilename(searchQuery)
-	filepath := filepath.Join(s.dataDir, filename)
-	normalizedQuery := s.normalizeSearchQuery(searchQuery)
+	data, changed, err := MigrateRecord(record.Kind, record.Data)
+	if err != nil {
+		return fmt.Errorf("failed to migrate %s: %v", filename, err)
+	}
+	if changed {
+		record.Data = data
+		if err := s.store.Put(record); err != nil {
+			fmt.Printf("⚠️  Warning: Could not save upgraded %s: %v\n", filename, err)
+		} else {
+			fmt.Printf("🔄 Upgraded %s to schema version %d\n", filename, SchemaVersion(record.Kind))
+		}
+	}
+
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", filename, err)
+	}
+	return nil
+}
+
+// SaveRecipes saves the recipes found for a query to persistent storage
+func (s *StorageService) SaveRecipes(recipes []Recipe, query IngredientQuery) error {
+	// Create key based on the canonical query
+	key := query.StorageKey()
+	filename := recordFilename(KindRecipes, key)
 
 	// Convert recipes to stored format
 	storedRecipes := make([]StoredRecipe, len(recipes))
@@ -83,8 +200,8 @@ func (s *StorageService) SaveRecipes(recipes []Recipe, searchQuery string) error
 		storedRecipes[i] = StoredRecipe{
 			Recipe:          recipe,
 			StoredAt:        time.Now(),
-			SearchQuery:     searchQuery,
-			NormalizedQuery: normalizedQuery,
+			SearchQuery:     query.Original(),
+			NormalizedQuery: query.String(),
 			Source:          "spoonacular",
 			Filename:        filename,
 		}
@@ -92,53 +209,40 @@ func (s *StorageService) SaveRecipes(recipes []Recipe, searchQuery string) error
 
 	// Create storage structure
 	storage := RecipeStorage{
-		LastUpdated: time.Now(),
-		Recipes:     storedRecipes,
+		SchemaVersion: SchemaVersion(KindRecipes),
+		LastUpdated:   time.Now(),
+		Recipes:       storedRecipes,
 	}
 
-	// Write to file
-	data, err := json.MarshalIndent(storage, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal recipes: %v", err)
+	if err := s.putJSON(KindRecipes, key, storage); err != nil {
+		return fmt.Errorf("failed to write recipes: %v", err)
 	}
 
-	if err := os.WriteFile(filepath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write recipes to file: %v", err)
+	for _, recipe := range recipes {
+		s.index.Add(searchResultFromSummary(recipe), false)
 	}
 
-	fmt.Printf("💾 Saved %d recipes to %s (query: %s)\n", len(recipes), filename, searchQuery)
+	fmt.Printf("💾 Saved %d recipes to %s (query: %s)\n", len(recipes), filename, query)
 	return nil
 }
 
-// LoadRecipes loads recipes from persistent storage
-func (s *StorageService) LoadRecipes(searchQuery string) ([]Recipe, error) {
-	s.fileMutex.RLock()
-	defer s.fileMutex.RUnlock()
-
-	filename := s.getFilename(searchQuery)
-	filepath := filepath.Join(s.dataDir, filename)
-
-	// Check if file exists
-	if _, err := os.Stat(filepath); os.IsNotExist(err) {
-		return nil, nil // No stored data, not an error
-	}
-
-	// Read file
-	data, err := os.ReadFile(filepath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read recipes file: %v", err)
-	}
+// LoadRecipes loads the recipes stored for a query from persistent storage, along with
+// when they were stored. It returns nil recipes if nothing is stored; callers decide
+// with Freshness whether the data is still usable.
+func (s *StorageService) LoadRecipes(query IngredientQuery) ([]Recipe, time.Time, error) {
+	key := query.StorageKey()
+	filename := recordFilename(KindRecipes, key)
 
-	// Parse JSON
 	var storage RecipeStorage
-	if err := json.Unmarshal(data, &storage); err != nil {
-		return nil, fmt.Errorf("failed to parse recipes file: %v", err)
+	err := s.getJSON(KindRecipes, key, &storage)
+	if errors.Is(err, ErrRecordNotFound) {
+		err = s.moveLegacyRecipes(query, &storage)
 	}
-
-	// Check if data is still fresh (within 7 days)
-	if time.Since(storage.LastUpdated) > 7*24*time.Hour {
-		fmt.Printf("📅 Stored data for '%s' is older than 7 days, will refresh\n", searchQuery)
-		return nil, nil // Data too old, treat as no data
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return nil, time.Time{}, nil // No stored data, not an error
+		}
+		return nil, time.Time{}, fmt.Errorf("failed to read recipes: %v", err)
 	}
 
 	// Convert back to Recipe format
@@ -147,39 +251,48 @@ func (s *StorageService) LoadRecipes(searchQuery string) ([]Recipe, error) {
 		recipes[i] = storedRecipe.Recipe
 	}
 
-	fmt.Printf("📂 Loaded %d recipes from %s (query: %s)\n", len(recipes), filename, searchQuery)
-	return recipes, nil
+	fmt.Printf("📂 Loaded %d recipes from %s (query: %s)\n", len(recipes), filename, query)
+	return recipes, storage.LastUpdated, nil
 }
 
-// GetAllStoredRecipes returns all stored recipes for browsing
-func (s *StorageService) GetAllStoredRecipes() ([]StoredRecipe, error) {
-	s.fileMutex.RLock()
-	defer s.fileMutex.RUnlock()
+// moveLegacyRecipes loads the recipes stored for a query under its key from before
+// queries were canonicalized and moves them to the current key. It returns
+// ErrRecordNotFound if there are none.
+func (s *StorageService) moveLegacyRecipes(query IngredientQuery, storage *RecipeStorage) error {
+	legacyKey, ok := query.LegacyStorageKey()
+	if !ok {
+		return ErrRecordNotFound
+	}
+	if err := s.getJSON(KindRecipes, legacyKey, storage); err != nil {
+		return err
+	}
 
-	var allRecipes []StoredRecipe
+	key := query.StorageKey()
+	if err := s.putJSON(KindRecipes, key, storage); err != nil {
+		fmt.Printf("⚠️  Warning: Could not move %s: %v\n", recordFilename(KindRecipes, legacyKey), err)
+		return nil
+	}
+	if err := s.store.Delete(KindRecipes, legacyKey); err != nil {
+		fmt.Printf("⚠️  Warning: Could not remove %s: %v\n", recordFilename(KindRecipes, legacyKey), err)
+	}
+	fmt.Printf("🔄 Moved %s to %s\n", recordFilename(KindRecipes, legacyKey), recordFilename(KindRecipes, key))
+	return nil
+}
 
-	// Read all JSON files in data directory
-	files, err := os.ReadDir(s.dataDir)
+// GetAllStoredRecipes returns all stored recipes for browsing
+func (s *StorageService) GetAllStoredRecipes() ([]StoredRecipe, error) {
+	records, err := s.store.List(KindRecipes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read data directory: %v", err)
+		return nil, err
 	}
 
-	for _, file := range files {
-		if !file.IsDir() && filepath.Ext(file.Name()) == ".json" {
-			filepath := filepath.Join(s.dataDir, file.Name())
-			
-			data, err := os.ReadFile(filepath)
-			if err != nil {
-				continue // Skip files we can't read
-			}
-
-			var storage RecipeStorage
-			if err := json.Unmarshal(data, &storage); err != nil {
-				continue // Skip files we can't parse
-			}
-
-			allRecipes = append(allRecipes, storage.Recipes...)
+	var allRecipes []StoredRecipe
+	for _, record := range records {
+		var storage RecipeStorage
+		if err := s.decodeRecord(record, &storage); err != nil {
+			continue // Skip records we can't parse
 		}
+		allRecipes = append(allRecipes, storage.Recipes...)
 	}
 
 	return allRecipes, nil
@@ -187,60 +300,55 @@ func (s *StorageService) GetAllStoredRecipes() ([]StoredRecipe, error) {
 
 // GetStorageStats returns statistics about stored data
 func (s *StorageService) GetStorageStats() (map[string]interface{}, error) {
-	s.fileMutex.RLock()
-	defer s.fileMutex.RUnlock()
-
 	stats := map[string]interface{}{
-		"totalFiles":   0,
-		"totalRecipes": 0,
-		"oldestEntry":  time.Now(),
-		"newestEntry":  time.Time{},
+		"backend":       s.store.Name(),
+		"totalFiles":    0,
+		"totalRecipes":  0,
+		"oldestEntry":   time.Now(),
+		"newestEntry":   time.Time{},
 		"searchQueries": []string{},
 	}
 
-	files, err := os.ReadDir(s.dataDir)
+	totalFiles := 0
+	for _, kind := range AllRecordKinds {
+		count, err := s.store.Count(kind)
+		if err != nil {
+			return stats, err
+		}
+		totalFiles += count
+	}
+
+	records, err := s.store.List(KindRecipes)
 	if err != nil {
-		return stats, fmt.Errorf("failed to read data directory: %v", err)
+		return stats, err
 	}
 
-	var searchQueries []string
+	searchQueries := []string{}
 	totalRecipes := 0
 	oldestEntry := time.Now()
 	newestEntry := time.Time{}
 
-	for _, file := range files {
-		if !file.IsDir() && filepath.Ext(file.Name()) == ".json" {
-			stats["totalFiles"] = stats["totalFiles"].(int) + 1
-			
-			filepath := filepath.Join(s.dataDir, file.Name())
-			data, err := os.ReadFile(filepath)
-			if err != nil {
-				continue
-			}
-
-			var storage RecipeStorage
-			if err := json.Unmarshal(data, &storage); err != nil {
-				continue
-			}
+	for _, record := range records {
+		var storage RecipeStorage
+		if err := s.decodeRecord(record, &storage); err != nil {
+			continue
+		}
 
-			totalRecipes += len(storage.Recipes)
-			
-			if storage.LastUpdated.Before(oldestEntry) {
-				oldestEntry = storage.LastUpdated
-			}
-			if storage.LastUpdated.After(newestEntry) {
-				newestEntry = storage.LastUpdated
-			}
+		totalRecipes += len(storage.Recipes)
 
-			// Extract search query from filename
-			query := s.getSearchQueryFromFilename(file.Name())
-			if query != "" {
-				searchQueries = append(searchQueries, query)
-			}
+		if storage.LastUpdated.Before(oldestEntry) {
+			oldestEntry = storage.LastUpdated
+		}
+		if storage.LastUpdated.After(newestEntry) {
+			newestEntry = storage.LastUpdated
 		}
+
+		searchQueries = append(searchQueries, searchQueryOf(record.Key, storage))
 	}
 
+	stats["totalFiles"] = totalFiles
 	stats["totalRecipes"] = totalRecipes
+	stats["indexedRecipes"] = s.index.Len()
 	stats["oldestEntry"] = oldestEntry
 	stats["newestEntry"] = newestEntry
 	stats["searchQueries"] = searchQueries
@@ -248,110 +356,33 @@ func (s *StorageService) GetStorageStats() (map[string]interface{}, error) {
 	return stats, nil
 }
 
-// CleanOldData removes data older than specified days
+// CleanOldData removes cached Spoonacular data older than specified days.
+// User-authored recipes are never expired.
 func (s *StorageService) CleanOldData(olderThanDays int) error {
-	s.fileMutex.Lock()
-	defer s.fileMutex.Unlock()
-
 	cutoffTime := time.Now().AddDate(0, 0, -olderThanDays)
-	
-	files, err := os.ReadDir(s.dataDir)
-	if err != nil {
-		return fmt.Errorf("failed to read data directory: %v", err)
-	}
 
 	removedCount := 0
-	for _, file := range files {
-		if !file.IsDir() && filepath.Ext(file.Name()) == ".json" {
-			filepath := filepath.Join(s.dataDir, file.Name())
-			
-			data, err := os.ReadFile(filepath)
-			if err != nil {
-				continue
-			}
-
-			var storage RecipeStorage
-			if err := json.Unmarshal(data, &storage); err != nil {
-				continue
-			}
-
-			if storage.LastUpdated.Before(cutoffTime) {
-				if err := os.Remove(filepath); err == nil {
-					removedCount++
-					fmt.Printf("🗑️  Removed old data file: %s\n", file.Name())
-				}
-			}
+	for _, kind := range []RecordKind{KindRecipes, KindIngredients, KindRecipeDetails} {
+		removed, err := s.store.DeleteOlderThan(kind, cutoffTime)
+		if err != nil {
+			return fmt.Errorf("failed to clean old %s: %v", kind, err)
 		}
+		removedCount += removed
 	}
 
 	if removedCount > 0 {
 		fmt.Printf("🧹 Cleaned %d old data files\n", removedCount)
+		s.rebuildIndex()
 	}
 
 	return nil
 }
 
-// getFilename generates a safe filename from search query using hash
-func (s *StorageService) getFilename(searchQuery string) string {
-	if searchQuery == "" {
-		return "popular_recipes.json"
-	}
-	
-	// Normalize the search query for consistent hashing
-	normalized := s.normalizeSearchQuery(searchQuery)
-	
-	// Create MD5 hash of normalized query
-	hasher := md5.New()
-	hasher.Write([]byte(normalized))
-	hash := hex.EncodeToString(hasher.Sum(nil))
-	
-	// Use first 12 characters of hash for shorter filenames
-	shortHash := hash[:12]
-	
-	return fmt.Sprintf("recipes_%s.json", shortHash)
-}
-
-// normalizeSearchQuery normalizes ingredients for consistent hashing
-func (s *StorageService) normalizeSearchQuery(searchQuery string) string {
-	if searchQuery == "" {
-		return ""
-	}
-	
-	// Split by comma, trim spaces, convert to lowercase, and sort
-	ingredients := strings.Split(searchQuery, ",")
-	normalized := make([]string, 0, len(ingredients))
-	
-	for _, ingredient := range ingredients {
-		trimmed := strings.TrimSpace(strings.ToLower(ingredient))
-		if trimmed != "" {
-			normalized = append(normalized, trimmed)
-		}
-	}
-	
-	// Sort ingredients to ensure consistent order
-	sort.Strings(normalized)
-	
-	// Join back with commas
-	return strings.Join(normalized, ",")
-}
-
-// getSearchQueryFromFilename extracts search query from stored recipe data
-func (s *StorageService) getSearchQueryFromFilename(filename string) string {
-	if filename == "popular_recipes.json" {
+// searchQueryOf returns the original search query of a stored recipes record
+func searchQueryOf(key string, storage RecipeStorage) string {
+	if strings.HasPrefix(key, popularRecipesKey) {
 		return "popular"
 	}
-	
-	// For hash-based filenames, we need to read the file to get the search query
-	filepath := filepath.Join(s.dataDir, filename)
-	data, err := os.ReadFile(filepath)
-	if err != nil {
-		return "unknown"
-	}
-
-	var storage RecipeStorage
-	if err := json.Unmarshal(data, &storage); err != nil {
-		return "unknown"
-	}
 
 	// Return the original search query from the first recipe
 	if len(storage.Recipes) > 0 {
@@ -363,36 +394,24 @@ func (s *StorageService) getSearchQueryFromFilename(filename string) string {
 
 // CreateFilenameMapping creates a mapping file for easier debugging
 func (s *StorageService) CreateFilenameMapping() error {
-	s.fileMutex.RLock()
-	defer s.fileMutex.RUnlock()
-
-	mapping := make(map[string]interface{})
-	
-	files, err := os.ReadDir(s.dataDir)
+	records, err := s.store.List(KindRecipes)
 	if err != nil {
-		return fmt.Errorf("failed to read data directory: %v", err)
+		return err
 	}
 
-	for _, file := range files {
-		if !file.IsDir() && filepath.Ext(file.Name()) == ".json" && file.Name() != "filename_mapping.json" {
-			filepath := filepath.Join(s.dataDir, file.Name())
-			data, err := os.ReadFile(filepath)
-			if err != nil {
-				continue
-			}
-
-			var storage RecipeStorage
-			if err := json.Unmarshal(data, &storage); err != nil {
-				continue
-			}
+	mapping := make(map[string]interface{})
+	for _, record := range records {
+		var storage RecipeStorage
+		if err := s.decodeRecord(record, &storage); err != nil {
+			continue
+		}
 
-			if len(storage.Recipes) > 0 {
-				mapping[file.Name()] = map[string]interface{}{
-					"searchQuery":     storage.Recipes[0].SearchQuery,
-					"normalizedQuery": storage.Recipes[0].NormalizedQuery,
-					"recipeCount":     len(storage.Recipes),
-					"lastUpdated":     storage.LastUpdated,
-				}
+		if len(storage.Recipes) > 0 {
+			mapping[recordFilename(KindRecipes, record.Key)] = map[string]interface{}{
+				"searchQuery":     storage.Recipes[0].SearchQuery,
+				"normalizedQuery": storage.Recipes[0].NormalizedQuery,
+				"recipeCount":     len(storage.Recipes),
+				"lastUpdated":     storage.LastUpdated,
 			}
 		}
 	}
@@ -403,8 +422,11 @@ func (s *StorageService) CreateFilenameMapping() error {
 		return fmt.Errorf("failed to marshal mapping: %v", err)
 	}
 
+	if err := s.ensureDataDir(); err != nil {
+		return err
+	}
 	mappingPath := filepath.Join(s.dataDir, "filename_mapping.json")
-	if err := os.WriteFile(mappingPath, mappingData, 0644); err != nil {
+	if err := writeFileAtomic(mappingPath, mappingData, 0644, time.Time{}); err != nil {
 		return fmt.Errorf("failed to write mapping file: %v", err)
 	}
 
@@ -414,12 +436,9 @@ func (s *StorageService) CreateFilenameMapping() error {
 
 // SaveIngredients saves ingredients to persistent storage with caching
 func (s *StorageService) SaveIngredients(ingredients []Ingredient, searchQuery string) error {
-	s.fileMutex.Lock()
-	defer s.fileMutex.Unlock()
-
-	// Create filename based on search query
-	filename := s.getIngredientFilename(searchQuery)
-	filepath := filepath.Join(s.dataDir, filename)
+	// Create key based on search query
+	key := s.getIngredientKey(searchQuery)
+	filename := recordFilename(KindIngredients, key)
 
 	// Convert ingredients to stored format
 	storedIngredients := make([]StoredIngredient, len(ingredients))
@@ -435,54 +454,31 @@ func (s *StorageService) SaveIngredients(ingredients []Ingredient, searchQuery s
 
 	// Create storage structure
 	storage := IngredientStorage{
-		Ingredients: storedIngredients,
-		LastUpdated: time.Now(),
-		SearchQuery: searchQuery,
-	}
-
-	// Write to file
-	data, err := json.MarshalIndent(storage, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal ingredients: %v", err)
+		SchemaVersion: SchemaVersion(KindIngredients),
+		Ingredients:   storedIngredients,
+		LastUpdated:   time.Now(),
+		SearchQuery:   searchQuery,
 	}
 
-	if err := os.WriteFile(filepath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write ingredients file: %v", err)
+	if err := s.putJSON(KindIngredients, key, storage); err != nil {
+		return fmt.Errorf("failed to write ingredients: %v", err)
 	}
 
 	fmt.Printf("💾 Saved %d ingredients to %s (query: %s)\n", len(ingredients), filename, searchQuery)
 	return nil
 }
 
-// LoadIngredients loads ingredients from persistent storage
-func (s *StorageService) LoadIngredients(searchQuery string) ([]Ingredient, error) {
-	s.fileMutex.RLock()
-	defer s.fileMutex.RUnlock()
+// LoadIngredients loads ingredients from persistent storage, along with when they were stored
+func (s *StorageService) LoadIngredients(searchQuery string) ([]Ingredient, time.Time, error) {
+	key := s.getIngredientKey(searchQuery)
+	filename := recordFilename(KindIngredients, key)
 
-	// Create filename based on search query
-	filename := s.getIngredientFilename(searchQuery)
-	filepath := filepath.Join(s.dataDir, filename)
-
-	// Check if file exists
-	if _, err := os.Stat(filepath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("ingredients file not found")
-	}
-
-	// Read file
-	data, err := os.ReadFile(filepath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read ingredients file: %v", err)
-	}
-
-	// Parse JSON
 	var storage IngredientStorage
-	if err := json.Unmarshal(data, &storage); err != nil {
-		return nil, fmt.Errorf("failed to parse ingredients file: %v", err)
-	}
-
-	// Check if data is fresh (within 7 days)
-	if time.Since(storage.LastUpdated) > 7*24*time.Hour {
-		return nil, fmt.Errorf("ingredients data is stale")
+	if err := s.getJSON(KindIngredients, key, &storage); err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return nil, time.Time{}, fmt.Errorf("ingredients file not found")
+		}
+		return nil, time.Time{}, fmt.Errorf("failed to read ingredients: %v", err)
 	}
 
 	// Convert back to our ingredient format
@@ -492,115 +488,77 @@ func (s *StorageService) LoadIngredients(searchQuery string) ([]Ingredient, erro
 	}
 
 	fmt.Printf("📂 Loaded %d ingredients from %s (query: %s)\n", len(ingredients), filename, searchQuery)
-	return ingredients, nil
+	return ingredients, storage.LastUpdated, nil
 }
 
-// getIngredientFilename generates a safe filename for ingredient search queries
-func (s *StorageService) getIngredientFilename(searchQuery string) string {
+// getIngredientKey generates a safe record key for ingredient search queries
+func (s *StorageService) getIngredientKey(searchQuery string) string {
 	// Normalize the search query for consistent hashing
 	normalized := strings.ToLower(strings.TrimSpace(searchQuery))
-	
+
 	// Create MD5 hash of normalized query
 	hasher := md5.New()
 	hasher.Write([]byte(normalized))
 	hash := hex.EncodeToString(hasher.Sum(nil))
-	
-	// Use first 12 characters of hash for shorter filenames
-	shortHash := hash[:12]
-	
-	return fmt.Sprintf("ingredients_%s.json", shortHash)
+
+	// Use first 12 characters of hash for shorter keys
+	return hash[:12]
 }
 
 // SaveRecipeDetails saves detailed recipe information to persistent storage
 func (s *StorageService) SaveRecipeDetails(recipeDetails *RecipeDetails, recipeID string) error {
-	s.fileMutex.Lock()
-	defer s.fileMutex.Unlock()
-
-	// Ensure data directory exists
-	if err := s.ensureDataDir(); err != nil {
-		return err
-	}
-
-	// Create filename for recipe details
-	filename := fmt.Sprintf("recipe_details_%s.json", recipeID)
-	filepath := filepath.Join(s.dataDir, filename)
+	filename := recordFilename(KindRecipeDetails, recipeID)
 
 	// Create storage data structure
 	data := RecipeDetailsStorage{
+		SchemaVersion: SchemaVersion(KindRecipeDetails),
 		RecipeDetails: recipeDetails,
 		Metadata: StorageMetadata{
-			Timestamp:   time.Now(),
-			RecipeID:    recipeID,
-			Source:      "spoonacular_details",
-			Filename:    filename,
+			Timestamp: time.Now(),
+			RecipeID:  recipeID,
+			Source:    "spoonacular_details",
+			Filename:  filename,
 		},
 	}
 
-	// Write to file
-	file, err := os.Create(filepath)
-	if err != nil {
-		return fmt.Errorf("failed to create recipe details file: %v", err)
+	if err := s.putJSON(KindRecipeDetails, recipeID, data); err != nil {
+		return fmt.Errorf("failed to write recipe details: %v", err)
 	}
-	defer file.Close()
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(data); err != nil {
-		return fmt.Errorf("failed to encode recipe details: %v", err)
-	}
+	s.index.Add(searchResultFromDetails(recipeDetails), true)
 
 	fmt.Printf("💾 Saved recipe details to %s\n", filename)
 	return nil
 }
 
-// LoadRecipeDetails loads detailed recipe information from persistent storage
-func (s *StorageService) LoadRecipeDetails(recipeID string) (*RecipeDetails, error) {
-	s.fileMutex.RLock()
-	defer s.fileMutex.RUnlock()
-
-	// Create filename for recipe details
-	filename := fmt.Sprintf("recipe_details_%s.json", recipeID)
-	filepath := filepath.Join(s.dataDir, filename)
-
-	// Check if file exists
-	if _, err := os.Stat(filepath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("recipe details file not found")
-	}
-
-	// Read file
-	file, err := os.Open(filepath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open recipe details file: %v", err)
-	}
-	defer file.Close()
-
-	// Decode data
+// LoadRecipeDetails loads detailed recipe information from persistent storage, along
+// with when it was stored
+func (s *StorageService) LoadRecipeDetails(recipeID string) (*RecipeDetails, time.Time, error) {
 	var data RecipeDetailsStorage
-	if err := json.NewDecoder(file).Decode(&data); err != nil {
-		return nil, fmt.Errorf("failed to decode recipe details: %v", err)
-	}
-
-	// Check if data is fresh (within 7 days)
-	if time.Since(data.Metadata.Timestamp) > 7*24*time.Hour {
-		return nil, fmt.Errorf("recipe details data is stale")
+	if err := s.getJSON(KindRecipeDetails, recipeID, &data); err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return nil, time.Time{}, fmt.Errorf("recipe details file not found")
+		}
+		return nil, time.Time{}, fmt.Errorf("failed to read recipe details: %v", err)
 	}
 
 	fmt.Printf("📂 Loaded recipe details from storage for recipe %s\n", recipeID)
-	return data.RecipeDetails, nil
+	return data.RecipeDetails, data.Metadata.Timestamp, nil
 }
 
 // RecipeDetailsStorage represents the storage structure for recipe details
 type RecipeDetailsStorage struct {
-	RecipeDetails *RecipeDetails    `json:"recipe_details"`
-	Metadata      StorageMetadata   `json:"metadata"`
+	SchemaVersion int             `json:"schemaVersion"`
+	RecipeDetails *RecipeDetails  `json:"recipeDetails"`
+	Metadata      StorageMetadata `json:"metadata"`
 }
 
 // StorageMetadata represents the metadata for stored data
 type StorageMetadata struct {
-	Timestamp   time.Time `json:"timestamp"`
-	RecipeID    string    `json:"recipeID"`
-	Source      string    `json:"source"`
-	Filename    string    `json:"filename"`
+	Timestamp time.Time `json:"timestamp"`
+	RecipeID  string    `json:"recipeId"`
+	Source    string    `json:"source"`
+	Filename  string    `json:"filename"`
 }
 
 // ensureDataDir ensures the data directory exists
@@ -609,4 +567,4 @@ func (s *StorageService) ensureDataDir() error {
 		return fmt.Errorf("failed to ensure data directory: %v", err)
 	}
 	return nil
-} 
\ No newline at end of file
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestStorage returns JSON storage in a temporary directory that is closed when the
+// test ends
+func newTestStorage(t *testing.T) *StorageService {
+	t.Helper()
+	storage, err := NewJSONStorageService(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewJSONStorageService() = %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	return storage
+}
+
+func TestLoadRecipesMovesLegacyRecords(t *testing.T) {
+	storage := newTestStorage(t)
+	query := ParseIngredientQuery("Tomatoes, basil")
+	legacyKey, ok := query.LegacyStorageKey()
+	if !ok {
+		t.Fatal("LegacyStorageKey() is not ok")
+	}
+
+	stored := RecipeStorage{
+		SchemaVersion: SchemaVersion(KindRecipes),
+		LastUpdated:   time.Now(),
+		Recipes:       []StoredRecipe{{Recipe: Recipe{ID: "1", Title: "Caprese"}}},
+	}
+	if err := storage.putJSON(KindRecipes, legacyKey, stored); err != nil {
+		t.Fatalf("putJSON() = %v", err)
+	}
+
+	recipes, _, err := storage.LoadRecipes(query)
+	if err != nil || len(recipes) != 1 || recipes[0].Title != "Caprese" {
+		t.Fatalf("LoadRecipes() = %+v, %v; want the legacy record's recipe", recipes, err)
+	}
+
+	// The record now lives under the canonical key only
+	var moved RecipeStorage
+	if err := storage.getJSON(KindRecipes, query.StorageKey(), &moved); err != nil {
+		t.Errorf("reading the canonical key: %v", err)
+	}
+	if err := storage.getJSON(KindRecipes, legacyKey, &moved); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("reading the legacy key = %v, want ErrRecordNotFound", err)
+	}
+
+	// Another spelling of the same search finds it under the canonical key
+	recipes, _, err = storage.LoadRecipes(ParseIngredientQuery("basil,tomato"))
+	if err != nil || len(recipes) != 1 {
+		t.Errorf("LoadRecipes() of the canonical spelling = %+v, %v; want 1 recipe", recipes, err)
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// RecordKind identifies what a stored record contains
+type RecordKind string
+
+// Kinds of records kept by StorageService
+const (
+	KindRecipes       RecordKind = "recipes"
+	KindIngredients   RecordKind = "ingredients"
+	KindRecipeDetails RecordKind = "recipe_details"
+	KindLocalRecipe   RecordKind = "local_recipe"
+	KindQuota         RecordKind = "quota"
+	KindPantryItem    RecordKind = "pantry_item"
+)
+
+// AllRecordKinds lists every kind of record, for maintenance tasks that visit all of them
+var AllRecordKinds = []RecordKind{KindRecipes, KindIngredients, KindRecipeDetails, KindLocalRecipe, KindQuota, KindPantryItem}
+
+// ErrRecordNotFound is returned by a Store when no record exists for a kind and key
+var ErrRecordNotFound = errors.New("record not found")
+
+// recordKeyPattern restricts keys to characters that are safe in filenames
+var recordKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// Record is a single JSON document persisted by a Store
+type Record struct {
+	Kind      RecordKind
+	Key       string
+	Data      []byte
+	UpdatedAt time.Time
+}
+
+// Store is the persistence backend behind StorageService. Implementations store
+// opaque JSON documents addressed by kind and key and must be safe for concurrent use.
+type Store interface {
+	// Get returns a record, or ErrRecordNotFound if it does not exist
+	Get(kind RecordKind, key string) (*Record, error)
+	// Put creates or replaces a record
+	Put(record Record) error
+	// Delete removes a record, returning ErrRecordNotFound if it does not exist
+	Delete(kind RecordKind, key string) error
+	// List returns every record of a kind
+	List(kind RecordKind) ([]Record, error)
+	// Count returns the number of records of a kind
+	Count(kind RecordKind) (int, error)
+	// DeleteOlderThan removes records of a kind last updated before cutoff and returns how many were removed
+	DeleteOlderThan(kind RecordKind, cutoff time.Time) (int, error)
+	// Name describes the backend for logs and stats
+	Name() string
+	// Dir returns the directory the store keeps its files in
+	Dir() string
+	// Close releases any resources held by the store
+	Close() error
+}
+
+// getStorageBackend returns the configured storage backend ("json" or "sqlite")
+func getStorageBackend() string {
+	if backend := os.Getenv("STORAGE_BACKEND"); backend != "" {
+		return strings.ToLower(strings.TrimSpace(backend))
+	}
+	return "json"
+}
+
+// getDataDir returns the data directory from environment variables
+func getDataDir() string {
+	if dir := os.Getenv("DATA_DIR"); dir != "" {
+		return dir
+	}
+	return "data"
+}
+
+// getSQLitePath returns the SQLite database path from environment variables
+func getSQLitePath(dataDir string) string {
+	if path := os.Getenv("SQLITE_PATH"); path != "" {
+		return path
+	}
+	return filepath.Join(dataDir, "recipes.db")
+}
+
+// NewStoreFromEnv opens the store selected by STORAGE_BACKEND
+func NewStoreFromEnv() (Store, error) {
+	dataDir := getDataDir()
+	switch backend := getStorageBackend(); backend {
+	case "json":
+		return NewJSONStore(dataDir)
+	case "sqlite":
+		return NewSQLiteStore(getSQLitePath(dataDir))
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (expected json or sqlite)", backend)
+	}
+}
+
+// validateRecordKey rejects keys that could escape the data directory or are otherwise unsafe
+func validateRecordKey(key string) error {
+	if !recordKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid record key %q", key)
+	}
+	return nil
+}
+
+// recordFilename returns the JSON filename used for a record, which also serves as
+// its human-readable name in stats and mappings
+func recordFilename(kind RecordKind, key string) string {
+	if kind == KindRecipes && key == popularRecipesKey {
+		return "popular_recipes.json"
+	}
+	return fmt.Sprintf("%s_%s.json", kind, key)
+}
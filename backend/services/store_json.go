@@ -0,0 +1,323 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lockFilename is the advisory lock file that serializes writers sharing a data directory
+const lockFilename = ".lock"
+
+// tempFilePattern names in-progress writes; leftovers from a crash are removed on startup
+const tempFilePattern = ".*.tmp-*"
+
+// JSONStore keeps one JSON file per record in a data directory. Writes go to a
+// temporary file that is fsynced and renamed into place, so readers never see a
+// partial file, and every operation takes an advisory lock on the directory so
+// several server processes can share it.
+type JSONStore struct {
+	dataDir string
+	mu      sync.RWMutex
+}
+
+// Ensure JSONStore satisfies Store
+var _ Store = (*JSONStore)(nil)
+
+// NewJSONStore creates a store rooted at dataDir, creating the directory if needed
+func NewJSONStore(dataDir string) (*JSONStore, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %v", err)
+	}
+
+	s := &JSONStore{dataDir: dataDir}
+	if err := s.removeTempFiles(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// lock takes the in-process lock and the directory's advisory lock
+func (s *JSONStore) lock(exclusive bool) (func(), error) {
+	if exclusive {
+		s.mu.Lock()
+	} else {
+		s.mu.RLock()
+	}
+	unlockInProcess := s.mu.Unlock
+	if !exclusive {
+		unlockInProcess = s.mu.RUnlock
+	}
+
+	unlockDir, err := lockFile(filepath.Join(s.dataDir, lockFilename), exclusive)
+	if err != nil {
+		unlockInProcess()
+		return nil, err
+	}
+
+	return func() {
+		unlockDir()
+		unlockInProcess()
+	}, nil
+}
+
+// removeTempFiles deletes temporary files left behind by writes interrupted by a crash
+func (s *JSONStore) removeTempFiles() error {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	leftovers, err := filepath.Glob(filepath.Join(s.dataDir, tempFilePattern))
+	if err != nil {
+		return fmt.Errorf("failed to list temporary files: %v", err)
+	}
+	for _, path := range leftovers {
+		if err := os.Remove(path); err == nil {
+			fmt.Printf("🧹 Removed interrupted write: %s\n", filepath.Base(path))
+		}
+	}
+	return nil
+}
+
+// Name describes the backend
+func (s *JSONStore) Name() string {
+	return "json:" + s.dataDir
+}
+
+// Dir returns the data directory
+func (s *JSONStore) Dir() string {
+	return s.dataDir
+}
+
+// Get reads a record file
+func (s *JSONStore) Get(kind RecordKind, key string) (*Record, error) {
+	if err := validateRecordKey(key); err != nil {
+		return nil, err
+	}
+
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return s.read(kind, key, recordFilename(kind, key))
+}
+
+// Put writes a record file
+func (s *JSONStore) Put(record Record) error {
+	if err := validateRecordKey(record.Key); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dataDir, 0755); err != nil {
+		return fmt.Errorf("failed to ensure data directory: %v", err)
+	}
+
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path := filepath.Join(s.dataDir, recordFilename(record.Kind, record.Key))
+	return writeFileAtomic(path, record.Data, 0644, record.UpdatedAt)
+}
+
+// Delete removes a record file
+func (s *JSONStore) Delete(kind RecordKind, key string) error {
+	if err := validateRecordKey(key); err != nil {
+		return err
+	}
+
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := os.Remove(filepath.Join(s.dataDir, recordFilename(kind, key))); err != nil {
+		if os.IsNotExist(err) {
+			return ErrRecordNotFound
+		}
+		return fmt.Errorf("failed to delete %s: %v", recordFilename(kind, key), err)
+	}
+	return nil
+}
+
+// List reads every record file of a kind
+func (s *JSONStore) List(kind RecordKind) ([]Record, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	keys, err := s.keys(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(keys))
+	for _, key := range keys {
+		record, err := s.read(kind, key, recordFilename(kind, key))
+		if err != nil {
+			continue // Skip files we can't read
+		}
+		records = append(records, *record)
+	}
+	return records, nil
+}
+
+// Count returns the number of record files of a kind
+func (s *JSONStore) Count(kind RecordKind) (int, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	keys, err := s.keys(kind)
+	return len(keys), err
+}
+
+// DeleteOlderThan removes record files of a kind whose modification time is before cutoff
+func (s *JSONStore) DeleteOlderThan(kind RecordKind, cutoff time.Time) (int, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	keys, err := s.keys(kind)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, key := range keys {
+		path := filepath.Join(s.dataDir, recordFilename(kind, key))
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(path); err == nil {
+			removed++
+			fmt.Printf("🗑️  Removed old data file: %s\n", filepath.Base(path))
+		}
+	}
+	return removed, nil
+}
+
+// Close is a no-op for the JSON store
+func (s *JSONStore) Close() error {
+	return nil
+}
+
+// read loads one record file; callers must hold the lock
+func (s *JSONStore) read(kind RecordKind, key, filename string) (*Record, error) {
+	path := filepath.Join(s.dataDir, filename)
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("failed to stat %s: %v", filename, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", filename, err)
+	}
+
+	return &Record{
+		Kind:      kind,
+		Key:       key,
+		Data:      data,
+		UpdatedAt: info.ModTime(),
+	}, nil
+}
+
+// keys lists the keys of every record file of a kind; callers must hold the lock
+func (s *JSONStore) keys(kind RecordKind) ([]string, error) {
+	files, err := os.ReadDir(s.dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data directory: %v", err)
+	}
+
+	prefix := string(kind) + "_"
+	keys := make([]string, 0)
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+		if kind == KindRecipes && name == recordFilename(KindRecipes, popularRecipesKey) {
+			keys = append(keys, popularRecipesKey)
+			continue
+		}
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		key := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".json")
+		if validateRecordKey(key) == nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// writeFileAtomic replaces path with data so that a crash leaves either the old or the
+// new contents, never a truncated file: the data is written to a temporary file in the
+// same directory, fsynced, renamed over path, and the directory entry is fsynced.
+func writeFileAtomic(path string, data []byte, perm os.FileMode, modTime time.Time) error {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+name+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for %s: %v", name, err)
+	}
+	tmpPath := tmp.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync %s: %v", name, err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return fmt.Errorf("failed to set permissions on %s: %v", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %v", name, err)
+	}
+	if !modTime.IsZero() {
+		os.Chtimes(tmpPath, modTime, modTime)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %v", name, err)
+	}
+	committed = true
+
+	// Persist the rename itself; not every platform supports syncing a directory
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "record.json")
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, data := range []string{`{"v":1}`, `{"v":2,"longer":true}`, `{}`} {
+		if err := writeFileAtomic(path, []byte(data), 0644, modTime); err != nil {
+			t.Fatalf("writeFileAtomic(%s) = %v", data, err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil || string(got) != data {
+			t.Errorf("file holds %q, %v, want %q", got, err, data)
+		}
+	}
+	if info, err := os.Stat(path); err != nil || !info.ModTime().Equal(modTime) {
+		t.Errorf("modification time = %v, want %v", info.ModTime(), modTime)
+	}
+	assertOnlyFiles(t, dir, "record.json")
+}
+
+func TestWriteFileAtomicFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	// Renaming over a non-empty directory fails after the data is written
+	target := filepath.Join(dir, "taken")
+	if err := os.MkdirAll(filepath.Join(target, "inside"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(target, []byte("data"), 0644, time.Time{}); err == nil {
+		t.Errorf("writeFileAtomic over a directory succeeded")
+	}
+	assertOnlyFiles(t, dir, "taken")
+
+	// Nothing is created when the directory doesn't exist
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "record.json"), []byte("data"), 0644, time.Time{}); err == nil {
+		t.Errorf("writeFileAtomic into a missing directory succeeded")
+	}
+	assertOnlyFiles(t, dir, "taken")
+}
+
+// assertOnlyFiles fails the test unless dir holds exactly the named entries, so no
+// temporary file was left behind
+func assertOnlyFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]bool)
+	for _, entry := range entries {
+		got[entry.Name()] = true
+	}
+	got[lockFilename] = false
+	for _, name := range names {
+		if !got[name] {
+			t.Errorf("%s is missing from %s", name, dir)
+		}
+		delete(got, name)
+	}
+	for name, present := range got {
+		if present {
+			t.Errorf("unexpected file %s left in %s", name, dir)
+		}
+	}
+}
+
+func TestJSONStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewJSONStore(dir)
+	if err != nil {
+		t.Fatalf("NewJSONStore() = %v", err)
+	}
+	updated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	records := []Record{
+		{Kind: KindLocalRecipe, Key: "one", Data: []byte(`{"n":1}`), UpdatedAt: updated},
+		{Kind: KindLocalRecipe, Key: "two", Data: []byte(`{"n":2}`), UpdatedAt: updated},
+		{Kind: KindRecipeDetails, Key: "one", Data: []byte(`{"n":3}`), UpdatedAt: updated},
+	}
+	for _, record := range records {
+		if err := store.Put(record); err != nil {
+			t.Fatalf("Put(%s/%s) = %v", record.Kind, record.Key, err)
+		}
+	}
+
+	got, err := store.Get(KindLocalRecipe, "one")
+	if err != nil || string(got.Data) != `{"n":1}` || !got.UpdatedAt.Equal(updated) {
+		t.Errorf("Get() = %+v, %v, want the record put", got, err)
+	}
+	if _, err := store.Get(KindLocalRecipe, "three"); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("Get() of a missing record = %v, want ErrRecordNotFound", err)
+	}
+	if _, err := store.Get(KindLocalRecipe, "../escape"); err == nil || errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("Get() with an unsafe key = %v, want it rejected", err)
+	}
+
+	// Replacing a record keeps one file for it
+	if err := store.Put(Record{Kind: KindLocalRecipe, Key: "two", Data: []byte(`{"n":22}`)}); err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+	list, err := store.List(KindLocalRecipe)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("List() = %d records, %v, want 2", len(list), err)
+	}
+	for _, record := range list {
+		if record.Key == "two" && string(record.Data) != `{"n":22}` {
+			t.Errorf("listed %s, want the replaced record", record.Data)
+		}
+	}
+	if n, err := store.Count(KindRecipeDetails); err != nil || n != 1 {
+		t.Errorf("Count() = %d, %v, want 1", n, err)
+	}
+
+	if err := store.Delete(KindLocalRecipe, "one"); err != nil {
+		t.Errorf("Delete() = %v", err)
+	}
+	if err := store.Delete(KindLocalRecipe, "one"); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("Delete() twice = %v, want ErrRecordNotFound", err)
+	}
+	if n, _ := store.Count(KindLocalRecipe); n != 1 {
+		t.Errorf("Count() after Delete = %d, want 1", n)
+	}
+
+	// Only records last updated before the cutoff go
+	removed, err := store.DeleteOlderThan(KindLocalRecipe, updated.Add(time.Hour))
+	if err != nil || removed != 0 {
+		t.Errorf("DeleteOlderThan() = %d, %v, want the fresh record kept", removed, err)
+	}
+	removed, err = store.DeleteOlderThan(KindRecipeDetails, updated.Add(time.Hour))
+	if err != nil || removed != 1 {
+		t.Errorf("DeleteOlderThan() = %d, %v, want the old record removed", removed, err)
+	}
+}
+
+func TestNewJSONStoreRemovesInterruptedWrites(t *testing.T) {
+	dir := t.TempDir()
+	leftover := filepath.Join(dir, ".local_recipe_one.json.tmp-123")
+	if err := os.WriteFile(leftover, []byte(`{"n":`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "local_recipe_one.json"), []byte(`{"n":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewJSONStore(dir)
+	if err != nil {
+		t.Fatalf("NewJSONStore() = %v", err)
+	}
+	assertOnlyFiles(t, dir, "local_recipe_one.json")
+	if record, err := store.Get(KindLocalRecipe, "one"); err != nil || string(record.Data) != `{"n":1}` {
+		t.Errorf("Get() = %v, want the complete record", err)
+	}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // pure-Go SQLite driver, no cgo required
+)
+
+// sqliteSchema creates the records table and its indexes
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS records (
+	kind       TEXT    NOT NULL,
+	key        TEXT    NOT NULL,
+	data       BLOB    NOT NULL,
+	updated_at INTEGER NOT NULL,
+	PRIMARY KEY (kind, key)
+);
+CREATE INDEX IF NOT EXISTS records_kind_updated_at ON records (kind, updated_at);
+`
+
+// SQLiteStore keeps every record in a single embedded SQLite database file
+type SQLiteStore struct {
+	path string
+	db   *sql.DB
+}
+
+// Ensure SQLiteStore satisfies Store
+var _ Store = (*SQLiteStore)(nil)
+
+// NewSQLiteStore opens (and if needed creates) the database at path
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %v", err)
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=%s&_pragma=%s&_txlock=immediate",
+		path, url.QueryEscape("busy_timeout(5000)"), url.QueryEscape("journal_mode(WAL)"))
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %v", err)
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create sqlite schema: %v", err)
+	}
+
+	return &SQLiteStore{path: path, db: db}, nil
+}
+
+// Name describes the backend
+func (s *SQLiteStore) Name() string {
+	return "sqlite:" + s.path
+}
+
+// Dir returns the directory holding the database file
+func (s *SQLiteStore) Dir() string {
+	return filepath.Dir(s.path)
+}
+
+// Get reads a record row
+func (s *SQLiteStore) Get(kind RecordKind, key string) (*Record, error) {
+	if err := validateRecordKey(key); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	var updatedAt int64
+	err := s.db.QueryRow(`SELECT data, updated_at FROM records WHERE kind = ? AND key = ?`, string(kind), key).
+		Scan(&data, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("failed to read %s record %s: %v", kind, key, err)
+	}
+
+	return &Record{
+		Kind:      kind,
+		Key:       key,
+		Data:      data,
+		UpdatedAt: time.Unix(0, updatedAt),
+	}, nil
+}
+
+// Put inserts or replaces a record row in a single transaction
+func (s *SQLiteStore) Put(record Record) error {
+	if err := validateRecordKey(record.Key); err != nil {
+		return err
+	}
+
+	updatedAt := record.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now()
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO records (kind, key, data, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (kind, key) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at`,
+		string(record.Kind), record.Key, record.Data, updatedAt.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to write %s record %s: %v", record.Kind, record.Key, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit %s record %s: %v", record.Kind, record.Key, err)
+	}
+	return nil
+}
+
+// Delete removes a record row
+func (s *SQLiteStore) Delete(kind RecordKind, key string) error {
+	if err := validateRecordKey(key); err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec(`DELETE FROM records WHERE kind = ? AND key = ?`, string(kind), key)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s record %s: %v", kind, key, err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// List returns every record row of a kind, ordered by key
+func (s *SQLiteStore) List(kind RecordKind) ([]Record, error) {
+	rows, err := s.db.Query(`SELECT key, data, updated_at FROM records WHERE kind = ? ORDER BY key`, string(kind))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s records: %v", kind, err)
+	}
+	defer rows.Close()
+
+	records := make([]Record, 0)
+	for rows.Next() {
+		var record Record
+		var updatedAt int64
+		if err := rows.Scan(&record.Key, &record.Data, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan %s record: %v", kind, err)
+		}
+		record.Kind = kind
+		record.UpdatedAt = time.Unix(0, updatedAt)
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list %s records: %v", kind, err)
+	}
+	return records, nil
+}
+
+// Count returns the number of record rows of a kind
+func (s *SQLiteStore) Count(kind RecordKind) (int, error) {
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM records WHERE kind = ?`, string(kind)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count %s records: %v", kind, err)
+	}
+	return count, nil
+}
+
+// DeleteOlderThan removes record rows of a kind last updated before cutoff
+func (s *SQLiteStore) DeleteOlderThan(kind RecordKind, cutoff time.Time) (int, error) {
+	result, err := s.db.Exec(`DELETE FROM records WHERE kind = ? AND updated_at < ?`, string(kind), cutoff.UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old %s records: %v", kind, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, nil
+	}
+	return int(n), nil
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
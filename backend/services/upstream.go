@@ -0,0 +1,364 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Errors that upstream failures are classified into; test with errors.Is.
+// ErrQuotaExhausted (see quota.go) is the fifth class.
+var (
+	// ErrUpstreamRateLimited means Spoonacular answered 429 and retries did not help
+	ErrUpstreamRateLimited = errors.New("spoonacular rate limit exceeded")
+	// ErrUpstreamNotFound means Spoonacular has no such resource
+	ErrUpstreamNotFound = errors.New("spoonacular resource not found")
+	// ErrUpstreamUnavailable means Spoonacular kept failing with network errors or 5xx responses
+	ErrUpstreamUnavailable = errors.New("spoonacular unavailable")
+	// ErrUpstreamRejected means Spoonacular rejected the request itself (other 4xx responses)
+	ErrUpstreamRejected = errors.New("spoonacular rejected the request")
+	// ErrCircuitOpen means calls are being skipped because Spoonacular has been failing
+	ErrCircuitOpen = errors.New("spoonacular circuit breaker open")
+)
+
+const (
+	// DefaultUpstreamMaxRetries is how many times a transient failure is retried
+	DefaultUpstreamMaxRetries = 2
+	// DefaultUpstreamRetryBase is the first backoff delay, doubled on every retry
+	DefaultUpstreamRetryBase = 200 * time.Millisecond
+	// DefaultUpstreamRetryMax caps a single backoff delay, including Retry-After
+	DefaultUpstreamRetryMax = 5 * time.Second
+	// DefaultBreakerThreshold is how many consecutive failed calls open the circuit
+	DefaultBreakerThreshold = 5
+	// DefaultBreakerCooldown is how long the circuit stays open before a trial call
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+// UpstreamError is a classified failure of a Spoonacular call. errors.Is matches it
+// against its class (ErrUpstreamRateLimited, ErrQuotaExhausted, ...). A call abandoned
+// because its context ended has context.Canceled or context.DeadlineExceeded as class.
+type UpstreamError struct {
+	Class      error         // One of the Err* classes
+	Endpoint   string        // Spoonacular endpoint name, e.g. "information"
+	StatusCode int           // HTTP status, or 0 for network errors
+	RetryAfter time.Duration // Delay Spoonacular asked for, if any
+	Err        error         // Underlying cause, if any
+}
+
+// Error describes the failure without the request URL, which carries the API key
+func (e *UpstreamError) Error() string {
+	message := fmt.Sprintf("%s: %v", e.Endpoint, e.Class)
+	if e.StatusCode != 0 {
+		message += fmt.Sprintf(" (status %d)", e.StatusCode)
+	}
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	return message
+}
+
+// Is matches the error's class
+func (e *UpstreamError) Is(target error) bool {
+	return target == e.Class
+}
+
+// Unwrap returns the underlying cause
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+// transient reports whether the failure is about Spoonacular's health rather than this
+// particular request: another attempt might succeed, and it counts towards opening the circuit
+func (e *UpstreamError) transient() bool {
+	return e.Class == ErrUpstreamUnavailable || e.Class == ErrUpstreamRateLimited
+}
+
+// abandoned reports whether the caller gave up on the call, which says nothing about
+// Spoonacular's health
+func (e *UpstreamError) abandoned() bool {
+	return e.Class == context.Canceled || e.Class == context.DeadlineExceeded
+}
+
+// sent reports whether the request went out to Spoonacular: calls refused by the quota
+// tracker, or that could not be built, never did and say nothing about its health
+func (e *UpstreamError) sent() bool {
+	return e.StatusCode != 0 || e.Class == ErrUpstreamUnavailable
+}
+
+// getEnvInt returns a non-negative integer from an environment variable with a fallback
+func getEnvInt(name string, fallback int) int {
+	if value := os.Getenv(name); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return fallback
+}
+
+// upstreamClient performs Spoonacular GET requests with quota accounting, retries with
+// jittered exponential backoff for transient failures, and a circuit breaker
+type upstreamClient struct {
+	client     *http.Client
+	quota      *QuotaTracker
+	breaker    *circuitBreaker
+	maxRetries int
+	retryBase  time.Duration
+	retryMax   time.Duration
+	sleep      func(ctx context.Context, d time.Duration) error
+}
+
+// newUpstreamClient creates a client configured from SPOONACULAR_MAX_RETRIES,
+// SPOONACULAR_RETRY_BASE_MS, CIRCUIT_BREAKER_THRESHOLD and CIRCUIT_BREAKER_COOLDOWN_SECONDS
+func newUpstreamClient(client *http.Client, quota *QuotaTracker) *upstreamClient {
+	threshold := getEnvInt("CIRCUIT_BREAKER_THRESHOLD", DefaultBreakerThreshold)
+	cooldown := time.Duration(getEnvInt("CIRCUIT_BREAKER_COOLDOWN_SECONDS", int(DefaultBreakerCooldown/time.Second))) * time.Second
+	retryBase := time.Duration(getEnvInt("SPOONACULAR_RETRY_BASE_MS", int(DefaultUpstreamRetryBase/time.Millisecond))) * time.Millisecond
+
+	return &upstreamClient{
+		client:     client,
+		quota:      quota,
+		breaker:    newCircuitBreaker(threshold, cooldown),
+		maxRetries: getEnvInt("SPOONACULAR_MAX_RETRIES", DefaultUpstreamMaxRetries),
+		retryBase:  retryBase,
+		retryMax:   DefaultUpstreamRetryMax,
+		sleep:      sleepContext,
+	}
+}
+
+// sleepContext waits for d, returning early with the context's error if it ends first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// getJSON calls a Spoonacular endpoint and decodes its JSON response into out. The
+// request and any backoff between retries stop as soon as ctx ends.
+func (c *upstreamClient) getJSON(ctx context.Context, endpoint, apiURL string, out interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return &UpstreamError{Class: err, Endpoint: endpoint}
+	}
+	if err := c.breaker.Allow(); err != nil {
+		return &UpstreamError{Class: ErrCircuitOpen, Endpoint: endpoint}
+	}
+
+	// lastSent is the outcome of the last attempt that reached Spoonacular; attempts
+	// refused before sending only end the retries
+	var lastErr, lastSent *UpstreamError
+	sent := false
+	for attempt := 0; ; attempt++ {
+		lastErr = c.attempt(ctx, endpoint, apiURL, out)
+		if lastErr == nil || lastErr.sent() {
+			lastSent, sent = lastErr, true
+		}
+		if lastErr == nil || !lastErr.transient() || attempt >= c.maxRetries {
+			break
+		}
+
+		delay := c.backoff(attempt)
+		if lastErr.RetryAfter > 0 {
+			if lastErr.RetryAfter > c.retryMax {
+				break // Not worth holding the request that long
+			}
+			delay = lastErr.RetryAfter
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			break // The caller will have given up before the retry
+		}
+		fmt.Printf("🔁 Retrying Spoonacular %s call in %v (attempt %d of %d): %v\n",
+			endpoint, delay.Round(time.Millisecond), attempt+2, c.maxRetries+1, lastErr)
+		if err := c.sleep(ctx, delay); err != nil {
+			lastErr = &UpstreamError{Class: err, Endpoint: endpoint}
+			break
+		}
+	}
+
+	switch {
+	case lastErr == nil:
+		c.breaker.Record(true)
+		return nil
+	case lastErr.abandoned(), !sent:
+		c.breaker.Release()
+	default:
+		c.breaker.Record(!lastSent.transient())
+	}
+	return lastErr
+}
+
+// attempt makes a single request and classifies its outcome
+func (c *upstreamClient) attempt(ctx context.Context, endpoint, apiURL string, out interface{}) *UpstreamError {
+	if err := c.quota.Allow(endpoint); err != nil {
+		return &UpstreamError{Class: ErrQuotaExhausted, Endpoint: endpoint}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return &UpstreamError{Class: ErrUpstreamRejected, Endpoint: endpoint, Err: fmt.Errorf("failed to build request: %v", err)}
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return &UpstreamError{Class: ctxErr, Endpoint: endpoint}
+		}
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err // Drop the URL, it contains the API key
+		}
+		return &UpstreamError{Class: ErrUpstreamUnavailable, Endpoint: endpoint, Err: err}
+	}
+	defer resp.Body.Close()
+	c.quota.Record(endpoint, resp)
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return &UpstreamError{Class: ctxErr, Endpoint: endpoint, StatusCode: resp.StatusCode}
+			}
+			return &UpstreamError{Class: ErrUpstreamUnavailable, Endpoint: endpoint, StatusCode: resp.StatusCode,
+				Err: fmt.Errorf("failed to decode response: %v", err)}
+		}
+		return nil
+	case resp.StatusCode == http.StatusPaymentRequired:
+		return &UpstreamError{Class: ErrQuotaExhausted, Endpoint: endpoint, StatusCode: resp.StatusCode}
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &UpstreamError{Class: ErrUpstreamRateLimited, Endpoint: endpoint, StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	case resp.StatusCode == http.StatusNotFound:
+		return &UpstreamError{Class: ErrUpstreamNotFound, Endpoint: endpoint, StatusCode: resp.StatusCode}
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout:
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		return &UpstreamError{Class: ErrUpstreamUnavailable, Endpoint: endpoint, StatusCode: resp.StatusCode}
+	default:
+		return &UpstreamError{Class: ErrUpstreamRejected, Endpoint: endpoint, StatusCode: resp.StatusCode}
+	}
+}
+
+// backoff returns the jittered delay before retry number attempt+1: a random duration
+// between half and all of retryBase * 2^attempt, capped at retryMax
+func (c *upstreamClient) backoff(attempt int) time.Duration {
+	delay := c.retryBase << uint(attempt)
+	if delay <= 0 || delay > c.retryMax {
+		delay = c.retryMax
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(at); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+// Circuit breaker states
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// circuitBreaker stops calls to Spoonacular after threshold consecutive failures. After
+// cooldown it lets a single trial call through: success closes the circuit again,
+// failure reopens it for another cooldown.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     string
+	failures  int
+	openedAt  time.Time
+	trial     bool // A half-open trial call is in flight
+}
+
+// newCircuitBreaker creates a closed breaker; a threshold of 0 disables it
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, state: breakerClosed}
+}
+
+// Allow returns ErrCircuitOpen if the call should be skipped
+func (b *circuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = breakerHalfOpen
+		b.trial = true
+		fmt.Printf("🔌 Spoonacular circuit half-open, trying one call\n")
+		return nil
+	case breakerHalfOpen:
+		if b.trial {
+			return ErrCircuitOpen // Only one trial call at a time
+		}
+		b.trial = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Release gives back an allowed call whose caller gave up before it finished, or that
+// never reached Spoonacular, without counting it as a success or a failure
+func (b *circuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
+
+// Record reports the outcome of an allowed call
+func (b *circuitBreaker) Record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if success {
+		if b.state != breakerClosed {
+			fmt.Printf("🔌 Spoonacular circuit closed, calls resumed\n")
+		}
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.threshold <= 0 {
+		return
+	}
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		if b.state != breakerOpen {
+			fmt.Printf("🔌 Spoonacular circuit open after %d consecutive failures, serving cached data for %v\n", b.failures, b.cooldown)
+		}
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
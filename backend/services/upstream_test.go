@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"recipe-finder-backend/fakespoonacular"
+)
+
+// newTestUpstream returns a client for the fake Spoonacular server that retries without
+// waiting and opens its circuit after threshold failed calls
+func newTestUpstream(t *testing.T, threshold int) (*upstreamClient, *fakespoonacular.Server, string) {
+	t.Helper()
+	fake, err := fakespoonacular.New()
+	if err != nil {
+		t.Fatalf("fakespoonacular.New: %v", err)
+	}
+	server := fake.Start()
+	t.Cleanup(server.Close)
+
+	client := &upstreamClient{
+		client:     server.Client(),
+		quota:      NewQuotaTracker(nil, 0),
+		breaker:    newCircuitBreaker(threshold, time.Hour),
+		maxRetries: DefaultUpstreamMaxRetries,
+		retryBase:  time.Millisecond,
+		retryMax:   time.Millisecond,
+		sleep:      func(ctx context.Context, d time.Duration) error { return ctx.Err() },
+	}
+	return client, fake, server.URL + "/recipes/660101/information?apiKey=test"
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		outcomes  []bool // Recorded in order, each after an allowed call
+		wantState string
+	}{
+		{"closed below threshold", 3, []bool{false, false}, breakerClosed},
+		{"opens at threshold", 3, []bool{false, false, false}, breakerOpen},
+		{"success resets the count", 3, []bool{false, false, true, false, false}, breakerClosed},
+		{"threshold 0 never opens", 0, []bool{false, false, false, false, false, false}, breakerClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCircuitBreaker(tt.threshold, time.Hour)
+			for _, success := range tt.outcomes {
+				if err := b.Allow(); err != nil {
+					t.Fatalf("Allow() = %v before the circuit opened", err)
+				}
+				b.Record(success)
+			}
+			if b.state != tt.wantState {
+				t.Errorf("state = %s, want %s", b.state, tt.wantState)
+			}
+			if err := b.Allow(); (err != nil) != (tt.wantState == breakerOpen) {
+				t.Errorf("Allow() = %v in state %s", err, b.state)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name      string
+		finish    func(b *circuitBreaker)
+		wantState string
+		wantAllow bool // Whether the next call is let through
+	}{
+		{"trial succeeds", func(b *circuitBreaker) { b.Record(true) }, breakerClosed, true},
+		{"trial fails", func(b *circuitBreaker) { b.Record(false) }, breakerOpen, false},
+		{"trial released", (*circuitBreaker).Release, breakerHalfOpen, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCircuitBreaker(1, time.Hour)
+			b.Allow()
+			b.Record(false)
+			b.openedAt = time.Now().Add(-2 * time.Hour)
+
+			if err := b.Allow(); err != nil {
+				t.Fatalf("Allow() after cooldown = %v, want a trial call", err)
+			}
+			if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("second Allow() during trial = %v, want ErrCircuitOpen", err)
+			}
+
+			tt.finish(b)
+			if b.state != tt.wantState {
+				t.Errorf("state = %s, want %s", b.state, tt.wantState)
+			}
+			if err := b.Allow(); (err == nil) != tt.wantAllow {
+				t.Errorf("Allow() after trial = %v, want allowed %v", err, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := &upstreamClient{retryBase: 100 * time.Millisecond, retryMax: time.Second}
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{0, 50 * time.Millisecond, 100 * time.Millisecond},
+		{1, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond, 800 * time.Millisecond},
+		{4, 500 * time.Millisecond, time.Second},
+		{62, 500 * time.Millisecond, time.Second}, // The shift overflows
+	}
+	for _, tt := range tests {
+		for i := 0; i < 50; i++ {
+			if delay := c.backoff(tt.attempt); delay < tt.min || delay > tt.max {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", tt.attempt, delay, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"0", 0},
+		{"-1", 0},
+		{"soon", 0},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(future); got <= 0 || got > time.Minute {
+		t.Errorf("parseRetryAfter(%q) = %v, want up to a minute", future, got)
+	}
+}
+
+func TestGetJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		status    int
+		wantErr   error
+		wantState string
+	}{
+		{"success", 0, 0, nil, breakerClosed},
+		{"retried until it succeeds", 2, http.StatusServiceUnavailable, nil, breakerClosed},
+		{"retries run out", 3, http.StatusServiceUnavailable, ErrUpstreamUnavailable, breakerOpen},
+		{"rate limited", 3, http.StatusTooManyRequests, ErrUpstreamRateLimited, breakerOpen},
+		{"rejected without retrying", 1, http.StatusBadRequest, ErrUpstreamRejected, breakerClosed},
+		{"quota spent upstream", 1, http.StatusPaymentRequired, ErrQuotaExhausted, breakerClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, fake, apiURL := newTestUpstream(t, 1)
+			if tt.failures > 0 {
+				fake.FailNext(tt.failures, tt.status)
+			}
+
+			var out SpoonacularRecipeInfo
+			err := client.getJSON(context.Background(), "information", apiURL, &out)
+			if tt.wantErr == nil && err != nil || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("getJSON() = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && out.ID != 660101 {
+				t.Errorf("decoded recipe %d, want 660101", out.ID)
+			}
+			// Only a successful call gets an answer from the fake
+			if answered := fake.Calls("information"); answered != 0 != (tt.wantErr == nil) {
+				t.Errorf("%d calls answered", answered)
+			}
+			if client.breaker.state != tt.wantState {
+				t.Errorf("breaker state = %s, want %s", client.breaker.state, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestGetJSONQuotaRefusalLeavesBreaker(t *testing.T) {
+	client, fake, apiURL := newTestUpstream(t, 1)
+	client.breaker.Allow()
+	client.breaker.Record(false)
+	client.breaker.openedAt = time.Now().Add(-2 * time.Hour)
+	spent := time.Now()
+	client.quota.usage.ExhaustedAt = &spent
+
+	var out SpoonacularRecipeInfo
+	if err := client.getJSON(context.Background(), "information", apiURL, &out); !errors.Is(err, ErrQuotaExhausted) {
+		t.Fatalf("getJSON() = %v, want ErrQuotaExhausted", err)
+	}
+	if answered := fake.Calls("information"); answered != 0 {
+		t.Errorf("%d calls answered past the spent budget", answered)
+	}
+	if client.breaker.state != breakerHalfOpen || client.breaker.trial {
+		t.Errorf("breaker state = %s with trial %v, want half-open and free for a trial call",
+			client.breaker.state, client.breaker.trial)
+	}
+}
+
+func TestGetJSONAbandoned(t *testing.T) {
+	client, fake, apiURL := newTestUpstream(t, 1)
+	fake.FailNext(1, http.StatusServiceUnavailable)
+
+	// The caller gives up during the backoff after the first failure
+	ctx, cancel := context.WithCancel(context.Background())
+	client.sleep = func(context.Context, time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+
+	var out SpoonacularRecipeInfo
+	if err := client.getJSON(ctx, "information", apiURL, &out); !errors.Is(err, context.Canceled) {
+		t.Fatalf("getJSON() = %v, want context.Canceled", err)
+	}
+	if client.breaker.state != breakerClosed || client.breaker.failures != 0 {
+		t.Errorf("breaker state = %s with %d failures, want closed with none counted",
+			client.breaker.state, client.breaker.failures)
+	}
+}
@@ -0,0 +1,176 @@
+package units
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// rung is a step of a unit ladder: a unit and the amount, in milliliters or grams, from
+// which it is preferred over the smaller units
+type rung struct {
+	unit Unit
+	from float64
+}
+
+// ladders list, per system and dimension, the units a cook measures in from smallest to
+// largest: 48 tsp is written as 1 cup, and 1/4 of 1/4 cup as 1 tbsp
+var ladders = map[System]map[Dimension][]rung{
+	US: {
+		Volume: {{Teaspoon, 0}, {Tablespoon, 3 * Teaspoon.size}, {Cup, Cup.size / 4}},
+		Mass:   {{Ounce, 0}, {Pound, Pound.size}},
+	},
+	Metric: {
+		Volume: {{Milliliter, 0}, {Liter, Liter.size}},
+		Mass:   {{Gram, 0}, {Kilogram, Kilogram.size}},
+	},
+}
+
+// kitchenFractions are the fractions measuring cups and spoons come in
+var kitchenFractions = []float64{0, 1.0 / 8, 1.0 / 4, 1.0 / 3, 1.0 / 2, 2.0 / 3, 3.0 / 4, 1}
+
+// Best moves q to the unit of its ladder that suits the amount, keeping its system.
+// Units on no ladder, like pints and cans, are kept.
+func Best(q Quantity) Quantity {
+	ladder := ladderOf(q.Unit)
+	if ladder == nil {
+		return q
+	}
+	return onLadder(q.Amount*q.Unit.size, ladder)
+}
+
+// ladderOf returns the ladder a unit is on, or nil
+func ladderOf(unit Unit) []rung {
+	for _, step := range ladders[unit.System][unit.Dimension] {
+		if step.unit == unit {
+			return ladders[unit.System][unit.Dimension]
+		}
+	}
+	return nil
+}
+
+// onLadder writes an amount in milliliters or grams in the largest unit of the ladder
+// that it fills
+func onLadder(base float64, ladder []rung) Quantity {
+	best := ladder[0]
+	for _, step := range ladder {
+		// Allow for float error: 3 tsp must reach 1 tbsp
+		if base >= step.from*(1-1e-9) {
+			best = step
+		}
+	}
+	return Quantity{base / best.unit.size, best.unit}
+}
+
+// ToSystem converts q to a measurement system, in the unit that suits the amount. Masses
+// and volumes change system; in metric, volumes of ingredients with a known density
+// that aren't liquids become grams. Counts, and everything when the system is Original,
+// are kept. The ingredient is only used to look up its density.
+func ToSystem(q Quantity, system System, ingredient string) Quantity {
+	if system == Original || q.Unit.IsZero() {
+		return q
+	}
+
+	switch q.Unit.Dimension {
+	case Temperature:
+		target := Fahrenheit
+		if system == Metric {
+			target = Celsius
+		}
+		converted, _ := Convert(q, target)
+		return converted
+	case Volume:
+		base := q.Amount * q.Unit.size
+		if system == Metric {
+			if density, ok := DensityOf(ingredient); ok && !density.Liquid {
+				return onLadder(base*density.GramsPerMilliliter, ladders[Metric][Mass])
+			}
+		}
+		if q.Unit.System == system && ladderOf(q.Unit) == nil {
+			return q // Pints and fluid ounces stay as written
+		}
+		return onLadder(base, ladders[system][Volume])
+	case Mass:
+		if q.Unit.System == system && ladderOf(q.Unit) == nil {
+			return q
+		}
+		return onLadder(q.Amount*q.Unit.size, ladders[system][Mass])
+	}
+	return q
+}
+
+// Round rounds an amount the way it would be measured: grams and milliliters to whole
+// numbers (to 5 from 100), kilograms and liters to hundredths, other large amounts to
+// whole numbers and the rest to measuring-cup fractions. A positive amount never rounds
+// to 0.
+func Round(q Quantity) Quantity {
+	amount := q.Amount
+	if amount <= 0 {
+		return q
+	}
+
+	switch q.Unit {
+	case Gram, Milliliter, Milligram:
+		switch {
+		case amount >= 100:
+			amount = math.Round(amount/5) * 5
+		case amount >= 10:
+			amount = math.Round(amount)
+		default:
+			amount = math.Max(math.Round(amount*10)/10, 0.1)
+		}
+		return Quantity{amount, q.Unit}
+	case Kilogram, Liter:
+		return Quantity{math.Max(math.Round(amount*100)/100, 0.01), q.Unit}
+	case Fahrenheit, Celsius:
+		return Quantity{math.Round(amount), q.Unit}
+	}
+
+	if amount >= 10 {
+		return Quantity{math.Round(amount), q.Unit}
+	}
+	whole, fraction := math.Modf(amount)
+	nearest := kitchenFractions[0]
+	for _, candidate := range kitchenFractions {
+		if math.Abs(candidate-fraction) < math.Abs(nearest-fraction) {
+			nearest = candidate
+		}
+	}
+	if whole == 0 && nearest == 0 {
+		nearest = kitchenFractions[1]
+	}
+	return Quantity{whole + nearest, q.Unit}
+}
+
+// Format writes a quantity's amount for its unit: decimals for metric units, fractions
+// otherwise
+func Format(q Quantity) string {
+	if q.Unit.System == Metric {
+		return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", q.Amount), "0"), ".")
+	}
+	return FormatAmount(q.Amount)
+}
+
+// FormatAmount writes an amount as a cook reads it: "2", "1 1/2" or "1/3". Amounts that
+// aren't close to a kitchen fraction are written as decimals.
+func FormatAmount(amount float64) string {
+	whole, fraction := math.Modf(amount)
+	if fraction < 0.01 {
+		return fmt.Sprintf("%d", int(whole))
+	}
+	if fraction > 0.99 {
+		return fmt.Sprintf("%d", int(whole)+1)
+	}
+	for _, f := range []struct {
+		value float64
+		text  string
+	}{{1.0 / 8, "1/8"}, {1.0 / 4, "1/4"}, {1.0 / 3, "1/3"}, {1.0 / 2, "1/2"}, {2.0 / 3, "2/3"}, {3.0 / 4, "3/4"}} {
+		if math.Abs(fraction-f.value) < 0.01 {
+			if whole == 0 {
+				return f.text
+			}
+			return fmt.Sprintf("%d %s", int(whole), f.text)
+		}
+	}
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", amount), "0"), ".")
+}
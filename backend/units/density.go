@@ -0,0 +1,93 @@
+package units
+
+import "strings"
+
+// Density relates an ingredient's volume to its mass
+type Density struct {
+	GramsPerMilliliter float64
+	Liquid             bool // Liquids are measured by volume even in metric kitchens
+}
+
+// densities of common ingredients as they are measured in cups and spoons: spooned and
+// leveled flour, packed brown sugar. Countable ingredients are keyed by their singular.
+var densities = map[string]Density{
+	"all purpose flour": {0.53, false},
+	"bread flour":       {0.54, false},
+	"whole wheat flour": {0.51, false},
+	"almond flour":      {0.41, false},
+	"flour":             {0.53, false},
+	"cornstarch":        {0.54, false},
+	"cocoa powder":      {0.42, false},
+	"cocoa":             {0.42, false},
+	"baking powder":     {0.81, false},
+	"baking soda":       {0.93, false},
+	"granulated sugar":  {0.85, false},
+	"brown sugar":       {0.93, false},
+	"powdered sugar":    {0.51, false},
+	"confectioners":     {0.51, false},
+	"sugar":             {0.85, false},
+	"salt":              {1.22, false},
+	"kosher salt":       {0.64, false},
+	"butter":            {0.96, false},
+	"shortening":        {0.81, false},
+	"rolled oat":        {0.38, false},
+	"oat":               {0.38, false},
+	"rice":              {0.78, false},
+	"quinoa":            {0.72, false},
+	"lentil":            {0.81, false},
+	"grated parmesan":   {0.42, false},
+	"parmesan":          {0.42, false},
+	"shredded cheese":   {0.47, false},
+	"cheese":            {0.47, false},
+	"chocolate chip":    {0.72, false},
+	"raisin":            {0.61, false},
+	"walnut":            {0.5, false},
+	"almond":            {0.6, false},
+	"peanut butter":     {1.08, false},
+	"breadcrumb":        {0.45, false},
+	"bread crumb":       {0.45, false},
+	"yogurt":            {1.03, false},
+	"sour cream":        {1.0, false},
+	"honey":             {1.42, true},
+	"maple syrup":       {1.32, true},
+	"molasses":          {1.4, true},
+	"water":             {1.0, true},
+	"milk":              {1.03, true},
+	"buttermilk":        {1.03, true},
+	"heavy cream":       {1.0, true},
+	"cream":             {1.0, true},
+	"broth":             {1.0, true},
+	"stock":             {1.0, true},
+	"juice":             {1.04, true},
+	"wine":              {0.99, true},
+	"vinegar":           {1.01, true},
+	"soy sauce":         {1.15, true},
+	"olive oil":         {0.92, true},
+	"vegetable oil":     {0.92, true},
+	"oil":               {0.92, true},
+	"vanilla extract":   {0.88, true},
+	"extract":           {0.88, true},
+}
+
+// DensityOf looks up an ingredient's density by name, singular or plural. The longest
+// entry found in the name wins, so "unsalted butter" is butter and "peanut butter" is
+// peanut butter.
+func DensityOf(ingredient string) (Density, bool) {
+	words := strings.Fields(strings.ReplaceAll(strings.ToLower(ingredient), "-", " "))
+	name := " " + strings.Join(words, " ") + " "
+	for i, word := range words {
+		words[i] = Singular(word)
+	}
+	singularName := " " + strings.Join(words, " ") + " "
+
+	best, bestLength := Density{}, 0
+	for key, density := range densities {
+		if len(key) <= bestLength {
+			continue
+		}
+		if strings.Contains(name, " "+key+" ") || strings.Contains(singularName, " "+key+" ") {
+			best, bestLength = density, len(key)
+		}
+	}
+	return best, bestLength > 0
+}
@@ -0,0 +1,51 @@
+package units
+
+import "strings"
+
+// irregularSingulars maps plurals that the suffix rules in Singular get wrong
+var irregularSingulars = map[string]string{
+	"leaves":    "leaf",
+	"loaves":    "loaf",
+	"halves":    "half",
+	"calves":    "calf",
+	"knives":    "knife",
+	"cookies":   "cookie",
+	"brownies":  "brownie",
+	"veggies":   "veggie",
+	"smoothies": "smoothie",
+	"pies":      "pie",
+	"quiches":   "quiche",
+	"fungi":     "fungus",
+	"mice":      "mouse",
+	"geese":     "goose",
+	"feet":      "foot",
+	"teeth":     "tooth",
+	"children":  "child",
+	"molasses":  "molasses",
+	"swiss":     "swiss",
+	"series":    "series",
+	"species":   "species",
+}
+
+// Singular returns the singular form of a lowercase English word using an irregulars
+// table and common suffix rules; words that are already singular are returned unchanged.
+// Ingredient names are matched by their singular, in densities as in searches.
+func Singular(word string) string {
+	if singular, ok := irregularSingulars[word]; ok {
+		return singular
+	}
+
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y" // berries -> berry
+	case len(word) > 4 && strings.HasSuffix(word, "oes"):
+		return word[:len(word)-2] // tomatoes -> tomato
+	case len(word) > 4 && (strings.HasSuffix(word, "ches") || strings.HasSuffix(word, "shes") ||
+		strings.HasSuffix(word, "sses") || strings.HasSuffix(word, "xes")):
+		return word[:len(word)-2] // peaches -> peach, radishes -> radish
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") &&
+		!strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		return word[:len(word)-1] // onions -> onion
+	}
+	return word
+}
@@ -0,0 +1,55 @@
+package units
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// temperaturePattern finds oven and frying temperatures in instructions: "350°F",
+// "350 °F", "180 degrees C", "350 degrees Fahrenheit", "180º C"
+var temperaturePattern = regexp.MustCompile(`(?i)(\d{2,3})\s*(?:°|º|˚|degrees?\s*)\s*(f|c|fahrenheit|celsius|centigrade)\b`)
+
+// convertTemperature converts between Fahrenheit and Celsius
+func convertTemperature(amount float64, from, to Unit) float64 {
+	switch {
+	case from == Fahrenheit && to == Celsius:
+		return (amount - 32) * 5 / 9
+	case from == Celsius && to == Fahrenheit:
+		return amount*9/5 + 32
+	}
+	return amount
+}
+
+// ConvertTemperatures rewrites the temperatures in instruction text in a system:
+// "Preheat the oven to 350°F" becomes "Preheat the oven to 175°C" in metric. Converted
+// temperatures are rounded to 5 degrees, as ovens are marked. Text is returned as is
+// for Original.
+func ConvertTemperatures(text string, system System) string {
+	if system != Metric && system != US {
+		return text
+	}
+	target := Fahrenheit
+	if system == Metric {
+		target = Celsius
+	}
+
+	return temperaturePattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := temperaturePattern.FindStringSubmatch(match)
+		degrees, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return match
+		}
+		from := Fahrenheit
+		if strings.HasPrefix(strings.ToLower(parts[2]), "c") {
+			from = Celsius
+		}
+		if from == target {
+			return match
+		}
+		converted := math.Round(convertTemperature(degrees, from, target)/5) * 5
+		return fmt.Sprintf("%d%s", int(converted), target.Symbol)
+	})
+}
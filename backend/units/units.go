@@ -0,0 +1,171 @@
+// Package units knows the units recipes measure ingredients in: volumes, masses, counts
+// and temperatures, in US customary and metric systems. It recognises units however
+// they are written, converts between them (volume to mass through ingredient
+// densities), picks the unit a cook would use for an amount and rounds amounts to what
+// can be measured.
+package units
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrIncompatible is returned when converting between units that measure different things
+var ErrIncompatible = errors.New("incompatible units")
+
+// Dimension is what a unit measures
+type Dimension int
+
+// Dimensions
+const (
+	Count       Dimension = iota // Pieces, cans, cloves, pinches: never converted
+	Volume                       // Based on milliliters
+	Mass                         // Based on grams
+	Temperature                  // Degrees, converted by formula
+)
+
+// System is a measurement system
+type System string
+
+// Measurement systems; Original keeps each unit as the source wrote it
+const (
+	Metric   System = "metric"
+	US       System = "us"
+	Original System = "original"
+	neutral  System = ""
+)
+
+// ParseSystem reads a units query value, accepting "" as Original
+func ParseSystem(value string) (System, error) {
+	switch system := System(strings.ToLower(strings.TrimSpace(value))); system {
+	case Metric, US, Original:
+		return system, nil
+	case neutral:
+		return Original, nil
+	}
+	return Original, fmt.Errorf("unknown units %q: use metric, us or original", value)
+}
+
+// Unit is a unit of measure
+type Unit struct {
+	Symbol    string // Short form, e.g. "tbsp"
+	Name      string // Singular long form, e.g. "tablespoon"
+	Plural    string // Plural long form, e.g. "tablespoons"
+	Dimension Dimension
+	System    System
+	size      float64 // In milliliters or grams
+}
+
+// Label returns the unit's long form for an amount, plural above one
+func (u Unit) Label(amount float64) string {
+	if amount > 1 {
+		return u.Plural
+	}
+	return u.Name
+}
+
+// IsZero reports whether u is the zero Unit, used for amounts without a unit
+func (u Unit) IsZero() bool {
+	return u.Symbol == ""
+}
+
+// Units by symbol
+var (
+	Teaspoon   = Unit{"tsp", "teaspoon", "teaspoons", Volume, US, 4.92892}
+	Tablespoon = Unit{"tbsp", "tablespoon", "tablespoons", Volume, US, 14.7868}
+	FluidOunce = Unit{"fl oz", "fluid ounce", "fluid ounces", Volume, US, 29.5735}
+	Cup        = Unit{"cup", "cup", "cups", Volume, US, 236.588}
+	Pint       = Unit{"pint", "pint", "pints", Volume, US, 473.176}
+	Quart      = Unit{"quart", "quart", "quarts", Volume, US, 946.353}
+	Gallon     = Unit{"gallon", "gallon", "gallons", Volume, US, 3785.41}
+	Milliliter = Unit{"ml", "milliliter", "milliliters", Volume, Metric, 1}
+	Liter      = Unit{"l", "liter", "liters", Volume, Metric, 1000}
+	Ounce      = Unit{"oz", "ounce", "ounces", Mass, US, 28.3495}
+	Pound      = Unit{"lb", "pound", "pounds", Mass, US, 453.592}
+	Milligram  = Unit{"mg", "milligram", "milligrams", Mass, Metric, 0.001}
+	Gram       = Unit{"g", "gram", "grams", Mass, Metric, 1}
+	Kilogram   = Unit{"kg", "kilogram", "kilograms", Mass, Metric, 1000}
+	Fahrenheit = Unit{"°F", "degree Fahrenheit", "degrees Fahrenheit", Temperature, US, 0}
+	Celsius    = Unit{"°C", "degree Celsius", "degrees Celsius", Temperature, Metric, 0}
+)
+
+// countUnit returns a unit for things counted rather than measured
+func countUnit(name, plural string) Unit {
+	return Unit{name, name, plural, Count, neutral, 0}
+}
+
+// known lists every unit Lookup recognises
+var known = []Unit{
+	Teaspoon, Tablespoon, FluidOunce, Cup, Pint, Quart, Gallon, Milliliter, Liter,
+	Ounce, Pound, Milligram, Gram, Kilogram, Fahrenheit, Celsius,
+	countUnit("pinch", "pinches"), countUnit("dash", "dashes"), countUnit("drop", "drops"),
+	countUnit("clove", "cloves"), countUnit("can", "cans"), countUnit("jar", "jars"),
+	countUnit("bottle", "bottles"), countUnit("package", "packages"), countUnit("bag", "bags"),
+	countUnit("box", "boxes"), countUnit("container", "containers"), countUnit("stick", "sticks"),
+	countUnit("slice", "slices"), countUnit("piece", "pieces"), countUnit("bunch", "bunches"),
+	countUnit("head", "heads"), countUnit("stalk", "stalks"), countUnit("sprig", "sprigs"),
+	countUnit("leaf", "leaves"), countUnit("sheet", "sheets"), countUnit("handful", "handfuls"),
+	countUnit("serving", "servings"), countUnit("large", "large"), countUnit("medium", "medium"),
+	countUnit("small", "small"),
+}
+
+// aliases maps the other ways units are written, lowercased and without a trailing
+// period, to their symbols. Symbols, long forms and plurals are recognised as well.
+var aliases = map[string]string{
+	"c": "cup", "tbs": "tbsp", "tbl": "tbsp", "tbsps": "tbsp", "tsps": "tsp",
+	"fl. oz": "fl oz", "lbs": "lb", "gr": "g",
+	"kgs": "kg", "kilo": "kg", "kilos": "kg", "millilitre": "ml", "millilitres": "ml",
+	"mls": "ml", "litre": "l", "litres": "l", "pt": "pint", "qt": "quart", "gal": "gallon",
+	"pkg": "package", "pkt": "package", "packet": "package", "packets": "package",
+	"extra-large": "large", "extra large": "large", "fahrenheit": "°F", "degrees f": "°F",
+	"celsius": "°C", "degrees c": "°C", "centigrade": "°C",
+}
+
+// bySpelling indexes every unit by each way of writing it
+var bySpelling = func() map[string]Unit {
+	index := make(map[string]Unit)
+	for _, unit := range known {
+		for _, spelling := range []string{unit.Symbol, unit.Name, unit.Plural, unit.Symbol + "s"} {
+			index[strings.ToLower(spelling)] = unit
+		}
+	}
+	for alias, symbol := range aliases {
+		index[alias] = index[strings.ToLower(symbol)]
+	}
+	return index
+}()
+
+// Lookup recognises a unit as it appears in recipes: "Tbsp.", "cups", "fl oz", "grams".
+// "T" and "t" are the only case-sensitive spellings: tablespoon and teaspoon.
+func Lookup(text string) (Unit, bool) {
+	text = strings.TrimSpace(text)
+	switch strings.TrimSuffix(text, ".") {
+	case "T", "Tbsp", "TBSP":
+		return Tablespoon, true
+	case "t":
+		return Teaspoon, true
+	}
+	unit, ok := bySpelling[strings.TrimSuffix(strings.ToLower(strings.Join(strings.Fields(text), " ")), ".")]
+	return unit, ok
+}
+
+// Quantity is an amount in a unit
+type Quantity struct {
+	Amount float64
+	Unit   Unit
+}
+
+// Convert expresses q in another unit of the same dimension
+func Convert(q Quantity, to Unit) (Quantity, error) {
+	if q.Unit == to {
+		return q, nil
+	}
+	if q.Unit.Dimension != to.Dimension || q.Unit.Dimension == Count {
+		return q, fmt.Errorf("%w: %s to %s", ErrIncompatible, q.Unit.Symbol, to.Symbol)
+	}
+	if q.Unit.Dimension == Temperature {
+		return Quantity{convertTemperature(q.Amount, q.Unit, to), to}, nil
+	}
+	return Quantity{q.Amount * q.Unit.size / to.size, to}, nil
+}
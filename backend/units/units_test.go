@@ -0,0 +1,228 @@
+package units
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		text   string
+		want   string // Symbol, "" when not a unit
+		wantOK bool
+	}{
+		{"cups", "cup", true},
+		{"Tbsp.", "tbsp", true},
+		{"T", "tbsp", true},
+		{"t", "tsp", true},
+		{"tablespoons", "tbsp", true},
+		{"fl  oz", "fl oz", true},
+		{"grams", "g", true},
+		{"kilos", "kg", true},
+		{"Litres", "l", true},
+		{"cloves", "clove", true},
+		{"extra-large", "large", true},
+		{"handful", "handful", true},
+		{"onion", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		unit, ok := Lookup(tt.text)
+		if ok != tt.wantOK || unit.Symbol != tt.want {
+			t.Errorf("Lookup(%q) = %q, %v, want %q, %v", tt.text, unit.Symbol, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		from    Quantity
+		to      Unit
+		want    float64
+		wantErr error
+	}{
+		{Quantity{1, Cup}, Tablespoon, 16, nil},
+		{Quantity{3, Teaspoon}, Tablespoon, 1, nil},
+		{Quantity{1, Liter}, Milliliter, 1000, nil},
+		{Quantity{1, Pound}, Ounce, 16, nil},
+		{Quantity{100, Gram}, Ounce, 3.5274, nil},
+		{Quantity{212, Fahrenheit}, Celsius, 100, nil},
+		{Quantity{180, Celsius}, Fahrenheit, 356, nil},
+		{Quantity{1, Cup}, Gram, 0, ErrIncompatible},
+		{Quantity{2, countUnit("clove", "cloves")}, countUnit("can", "cans"), 0, ErrIncompatible},
+	}
+	for _, tt := range tests {
+		got, err := Convert(tt.from, tt.to)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("Convert(%v %s, %s) error = %v, want %v", tt.from.Amount, tt.from.Unit.Symbol, tt.to.Symbol, err, tt.wantErr)
+			continue
+		}
+		if err == nil && math.Abs(got.Amount-tt.want) > 0.001 {
+			t.Errorf("Convert(%v %s, %s) = %v, want %v", tt.from.Amount, tt.from.Unit.Symbol, tt.to.Symbol, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestBest(t *testing.T) {
+	tests := []struct {
+		in       Quantity
+		want     float64
+		wantUnit Unit
+	}{
+		{Quantity{48, Teaspoon}, 1, Cup},
+		{Quantity{3, Teaspoon}, 1, Tablespoon},
+		{Quantity{0.25, Cup}, 0.25, Cup},
+		{Quantity{0.125, Cup}, 2, Tablespoon},
+		{Quantity{1500, Gram}, 1.5, Kilogram},
+		{Quantity{0.5, Liter}, 500, Milliliter},
+		{Quantity{32, Ounce}, 2, Pound},
+		{Quantity{2, Pint}, 2, Pint}, // On no ladder
+	}
+	for _, tt := range tests {
+		got := Best(tt.in)
+		if got.Unit != tt.wantUnit || math.Abs(got.Amount-tt.want) > 0.001 {
+			t.Errorf("Best(%v %s) = %v %s, want %v %s", tt.in.Amount, tt.in.Unit.Symbol, got.Amount, got.Unit.Symbol, tt.want, tt.wantUnit.Symbol)
+		}
+	}
+}
+
+func TestToSystem(t *testing.T) {
+	tests := []struct {
+		in         Quantity
+		system     System
+		ingredient string
+		want       float64
+		wantUnit   Unit
+	}{
+		{Quantity{1, Cup}, Metric, "all-purpose flour", 125.39, Gram},
+		{Quantity{1, Cup}, Metric, "milk", 236.588, Milliliter},
+		{Quantity{1, Cup}, Metric, "chopped walnuts", 118.29, Gram},
+		{Quantity{1, Cup}, Metric, "walnut halves", 118.29, Gram},
+		{Quantity{500, Gram}, US, "butter", 1.1023, Pound},
+		{Quantity{200, Gram}, US, "butter", 7.0548, Ounce},
+		{Quantity{2, Pint}, US, "milk", 2, Pint},
+		{Quantity{350, Fahrenheit}, Metric, "", 176.667, Celsius},
+		{Quantity{1, Cup}, Original, "flour", 1, Cup},
+	}
+	for _, tt := range tests {
+		got := ToSystem(tt.in, tt.system, tt.ingredient)
+		if got.Unit != tt.wantUnit || math.Abs(got.Amount-tt.want) > 0.01 {
+			t.Errorf("ToSystem(%v %s, %s, %q) = %v %s, want %v %s", tt.in.Amount, tt.in.Unit.Symbol, tt.system,
+				tt.ingredient, got.Amount, got.Unit.Symbol, tt.want, tt.wantUnit.Symbol)
+		}
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		in   Quantity
+		want float64
+	}{
+		{Quantity{0.3, Cup}, 1.0 / 3},
+		{Quantity{1.45, Cup}, 1.5},
+		{Quantity{0.01, Teaspoon}, 1.0 / 8}, // Never rounds to nothing
+		{Quantity{12.4, Tablespoon}, 12},
+		{Quantity{123, Gram}, 125},
+		{Quantity{12.4, Gram}, 12},
+		{Quantity{0.04, Gram}, 0.1},
+		{Quantity{1.234, Kilogram}, 1.23},
+		{Quantity{176.7, Celsius}, 177},
+	}
+	for _, tt := range tests {
+		if got := Round(tt.in); math.Abs(got.Amount-tt.want) > 1e-9 {
+			t.Errorf("Round(%v %s) = %v, want %v", tt.in.Amount, tt.in.Unit.Symbol, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		in   Quantity
+		want string
+	}{
+		{Quantity{2, Cup}, "2"},
+		{Quantity{1.5, Cup}, "1 1/2"},
+		{Quantity{1.0 / 3, Cup}, "1/3"},
+		{Quantity{0.999, Cup}, "1"},
+		{Quantity{0.4, Cup}, "0.4"},
+		{Quantity{1.25, Kilogram}, "1.25"},
+		{Quantity{1.5, Liter}, "1.5"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.in); got != tt.want {
+			t.Errorf("Format(%v %s) = %q, want %q", tt.in.Amount, tt.in.Unit.Symbol, got, tt.want)
+		}
+	}
+}
+
+func TestDensityOf(t *testing.T) {
+	tests := []struct {
+		ingredient string
+		want       float64
+		wantOK     bool
+	}{
+		{"flour", 0.53, true},
+		{"All-Purpose Flour", 0.53, true},
+		{"almond flour", 0.41, true},
+		{"unsalted butter", 0.96, true},
+		{"peanut butter", 1.08, true},
+		{"walnut", 0.5, true},
+		{"chopped walnuts", 0.5, true},
+		{"raisin", 0.61, true},
+		{"red lentils", 0.81, true},
+		{"chocolate chip", 0.72, true},
+		{"molasses", 1.4, true},
+		{"oat milk", 1.03, true},
+		{"chicken breast", 0, false},
+	}
+	for _, tt := range tests {
+		density, ok := DensityOf(tt.ingredient)
+		if ok != tt.wantOK || density.GramsPerMilliliter != tt.want {
+			t.Errorf("DensityOf(%q) = %v, %v, want %v, %v", tt.ingredient, density.GramsPerMilliliter, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSingular(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"onions", "onion"},
+		{"berries", "berry"},
+		{"tomatoes", "tomato"},
+		{"peaches", "peach"},
+		{"leaves", "leaf"},
+		{"cookies", "cookie"},
+		{"molasses", "molasses"},
+		{"hummus", "hummus"},
+		{"grass", "grass"},
+		{"egg", "egg"},
+	}
+	for _, tt := range tests {
+		if got := Singular(tt.word); got != tt.want {
+			t.Errorf("Singular(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestParseSystem(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    System
+		wantErr bool
+	}{
+		{"", Original, false},
+		{"Metric", Metric, false},
+		{" us ", US, false},
+		{"original", Original, false},
+		{"imperial", Original, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSystem(tt.value)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseSystem(%q) = %q, %v, want %q, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
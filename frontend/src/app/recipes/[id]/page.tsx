@@ -16,7 +16,7 @@ import {
   Shield
 } from 'lucide-react'
 import { saveRecipe, unsaveRecipe, isRecipeSaved } from '@/utils/savedRecipes'
-import { API_ENDPOINTS } from '@/utils/api'
+import { API_ENDPOINTS, readApiError } from '@/utils/api'
 import SocialShare from '@/components/SocialShare'
 
 interface DetailedIngredient {
@@ -24,6 +24,7 @@ interface DetailedIngredient {
   name: string
   originalName: string
   amount: number
+  amountMax?: number
   unit: string
   unitLong: string
   original: string
@@ -93,7 +94,8 @@ export default function RecipeDetailPage() {
       const response = await fetch(`${API_ENDPOINTS.RECIPE_DETAILS}/${recipeId}`)
       
       if (!response.ok) {
-        throw new Error('Failed to fetch recipe details')
+        const apiError = await readApiError(response, 'Failed to fetch recipe details')
+        throw new Error(apiError.message)
       }
       
       const data = await response.json()
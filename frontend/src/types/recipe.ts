@@ -10,6 +10,32 @@ export interface Recipe {
   servings: number
   imageUrl: string
   matchCount: number
+  missingCount?: number
+  matchScore?: number
+  matches?: IngredientMatch[]
+  usedIngredients?: RecipeIngredient[]
+  missedIngredients?: RecipeIngredient[]
+  unusedIngredients?: string[]
+}
+
+// An ingredient of a search result with its measurements
+export interface RecipeIngredient {
+  id: number
+  name: string
+  amount: number
+  unit: string
+  original: string
+  aisle: string
+  image: string
+}
+
+// How one recipe ingredient was matched to the user's ingredients
+export interface IngredientMatch {
+  ingredient: string
+  matchedBy?: string
+  kind: 'exact' | 'synonym' | 'specific' | 'family' | 'general' | 'broad' | 'upstream' | 'none'
+  score: number
+  explanation: string
 }
 
 export interface CreateRecipeRequest {
@@ -10,4 +10,25 @@ export const API_ENDPOINTS = {
   RECIPE_DETAILS: `${API_BASE_URL}/api/v1/recipes`,
 } as const
 
+// Error envelope returned by the backend for every failed request
+export interface ApiError {
+  code: string
+  message: string
+  requestId?: string
+  details?: Record<string, unknown>
+}
+
+// Reads the error envelope of a failed response, falling back to a generic error
+export async function readApiError(response: Response, fallback: string): Promise<ApiError> {
+  try {
+    const body = await response.json()
+    if (body && body.error && typeof body.error.message === 'string') {
+      return body.error as ApiError
+    }
+  } catch {
+    // Not JSON (e.g. a proxy error page)
+  }
+  return { code: 'unknown', message: fallback }
+}
+
 export { API_BASE_URL } 
\ No newline at end of file